@@ -9,14 +9,22 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	c "github.com/VersusControl/versus-incident/pkg/config"
 	"github.com/VersusControl/versus-incident/pkg/controllers"
 	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/grouping"
 	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/resolve"
 	"github.com/VersusControl/versus-incident/pkg/routes"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/VersusControl/versus-incident/pkg/schedule"
 	"github.com/VersusControl/versus-incident/pkg/scheduler"
 	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/silence"
+	"github.com/VersusControl/versus-incident/pkg/store"
+	"github.com/VersusControl/versus-incident/pkg/storm"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssmincidents"
 	"github.com/go-redis/redis/v8"
@@ -34,6 +42,17 @@ func main() {
 
 	cfg := c.GetConfig()
 
+	if err := routing.InitRouter(cfg.Routes, cfg.Teams); err != nil {
+		log.Fatalf("Failed to initialize routing: %v", err)
+	}
+
+	if err := resolve.InitResolver(cfg.ResolveRules); err != nil {
+		log.Fatalf("Failed to initialize resolve rules: %v", err)
+	}
+
+	storm.InitDetector()
+	grouping.InitGrouper()
+
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true, // Disable the default Fiber banner
 	})
@@ -42,38 +61,27 @@ func main() {
 
 	routes.SetupRoutes(app)
 
-	// Start queue listeners
-	if cfg.Queue.Enable {
-		listenerFactory := common.NewListenerFactory(cfg)
-		listeners, err := listenerFactory.CreateListeners()
-		if err != nil {
-			log.Fatalf("Failed to create queue listeners: %v", err)
-		}
+	var redisClient *redis.Client
 
-		if cfg.Queue.SNS.Enable {
-			app.Post(cfg.Queue.SNS.EndpointPath, controllers.SNS)
-		}
+	// Routes and teams that enable on-call need the workflow even when it's disabled globally
+	onCall := cfg.OnCall.Enable || cfg.OnCall.InitializedOnly || routing.GetRouter().EnablesOnCall()
 
-		for _, listener := range listeners {
-			go func(l core.QueueListener) {
-				if err := l.StartListening(handleQueueMessage); err != nil {
-					log.Printf("Listener error: %v", err)
-				}
-			}(listener)
-		}
-	}
+	// Redis streams reuse the connection of on-call and silences
+	redisStreams := cfg.Queue.Enable && cfg.Queue.RedisStreams.Enable
 
-	if cfg.OnCall.Enable || cfg.OnCall.InitializedOnly {
+	if onCall || cfg.Silences.Enable || redisStreams {
 		redisOptions := handlerRedisOptions(cfg.Redis)
 
 		// Initialize Redis client
-		redisClient := redis.NewClient(redisOptions)
+		redisClient = redis.NewClient(redisOptions)
 
 		// Test Redis connection
 		if err := redisClient.Ping(context.Background()).Err(); err != nil {
 			log.Fatal("Redis connection failed:", err)
 		}
+	}
 
+	if onCall {
 		awsCfg, err := config.LoadDefaultConfig(context.Background())
 		if err != nil {
 			log.Fatal("Failed to load AWS config:", err)
@@ -83,6 +91,38 @@ func main() {
 		core.InitOnCallWorkflow(awsClient, redisClient)
 	}
 
+	// Record incidents in Redis when it's available, otherwise in memory.
+	// The firing incidents are only indexed for the inhibit rules and auto-resolve.
+	store.InitIncidentStore(redisClient, len(cfg.InhibitRules) > 0 || routing.GetRouter().AutoResolves())
+
+	if cfg.Silences.Enable {
+		if err := silence.InitStore(cfg.Silences, redisClient); err != nil {
+			log.Fatalf("Failed to initialize silences: %v", err)
+		}
+	}
+
+	if len(cfg.InhibitRules) > 0 {
+		if err := silence.InitInhibitor(cfg.InhibitRules, store.GetIncidentStore()); err != nil {
+			log.Fatalf("Failed to initialize inhibit rules: %v", err)
+		}
+	}
+
+	// Resolve the silent incidents of the routes with auto_resolve_after
+	var stopAutoResolver func()
+	if routing.GetRouter().AutoResolves() {
+		stopAutoResolver = services.StartAutoResolver(time.Minute)
+	}
+
+	// Initialize on-call schedules, API changes are kept in Redis when on-call is enabled
+	var stopHandoffWatcher func()
+	if cfg.OnCallSchedules.Enable {
+		if err := schedule.InitStore(cfg.OnCallSchedules, redisClient); err != nil {
+			log.Fatalf("Failed to initialize on-call schedules: %v", err)
+		}
+
+		stopHandoffWatcher = schedule.GetStore().StartHandoffWatcher(common.NewHandoffNotifier(cfg), time.Minute)
+	}
+
 	// Initialize and start scheduled alert jobs
 	var alertScheduler *scheduler.Scheduler
 	if cfg.ScheduledAlert.Enable {
@@ -94,6 +134,35 @@ func main() {
 		controllers.SetScheduler(alertScheduler)
 	}
 
+	// Start queue listeners once the incidents can be recorded and silenced
+	if cfg.Queue.Enable {
+		listenerFactory := common.NewListenerFactory(cfg, redisClient)
+		listeners, err := listenerFactory.CreateListeners()
+		if err != nil {
+			log.Fatalf("Failed to create queue listeners: %v", err)
+		}
+
+		if cfg.Queue.SNS.Enable {
+			app.Post(cfg.Queue.SNS.EndpointPath, controllers.SNS)
+		}
+
+		controllers.SetQueueListeners(listeners)
+
+		for _, listener := range listeners {
+			go func(l core.QueueListener) {
+				var err error
+				if tl, ok := l.(core.TeamQueueListener); ok {
+					err = tl.StartTeamListening(handleTeamQueueMessage)
+				} else {
+					err = l.StartListening(handleQueueMessage)
+				}
+				if err != nil {
+					log.Printf("Listener error: %v", err)
+				}
+			}(listener)
+		}
+	}
+
 	// Setup graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -104,6 +173,12 @@ func main() {
 		if alertScheduler != nil {
 			alertScheduler.Stop()
 		}
+		if stopHandoffWatcher != nil {
+			stopHandoffWatcher()
+		}
+		if stopAutoResolver != nil {
+			stopAutoResolver()
+		}
 		app.Shutdown()
 	}()
 
@@ -137,8 +212,12 @@ V       V   EEEEE   RRRRR   SSSSS   U       U   SSSSS
 └───────────────────────────────────────────────────┘
 
 /api/incidents    -> receive incident data
+/api/teams        -> receive incident data for a team
 /api%s       -> receive alerts from AWS SNS
 /api/ack          -> acknowledge on-call alerts
+/api/silences     -> silences and maintenance windows
+/api/schedules    -> on-call schedules and rotations
+/api/routes/test  -> show which routes an alert hits
 Scheduled Alerts  -> %s
 `, cfg.Host, cfg.Port, cfg.Queue.SNS.EndpointPath, schedulerStatus)
 }
@@ -147,6 +226,11 @@ func handleQueueMessage(content *map[string]interface{}) error {
 	return services.CreateIncident("", content) // teamID as empty string
 }
 
+// handleTeamQueueMessage handles the messages of listeners that know their team, e.g. the SMTP listener
+func handleTeamQueueMessage(teamID string, content *map[string]interface{}) error {
+	return services.CreateIncident(teamID, content)
+}
+
 func handlerRedisOptions(rc c.RedisConfig) *redis.Options {
 	redisOptions := &redis.Options{
 		Addr:     rc.Host + ":" + strconv.Itoa(rc.Port),
@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssmincidents"
 )
@@ -25,7 +26,9 @@ func NewAwsIncidentManagerProvider(client *ssmincidents.Client, responsePlanArn
 }
 
 // TriggerOnCall creates an incident in AWS Incident Manager
-func (p *AwsIncidentManagerProvider) TriggerOnCall(ctx context.Context, incidentID string, cfg *config.OnCallConfig) error {
+func (p *AwsIncidentManagerProvider) TriggerOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	incidentID := incident.ID
+
 	// Use the override config if provided, otherwise use the default
 	responsePlanArn := p.responsePlanArn
 	if cfg != nil && cfg.AwsIncidentManager.ResponsePlanArn != "" {
@@ -3,9 +3,15 @@ package common
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"html"
 	"html/template"
+	"log"
+	"mime/multipart"
+	"net/http"
 	"net/smtp"
+	"net/textproto"
 	"path/filepath"
 	"strings"
 
@@ -22,6 +28,8 @@ type EmailProvider struct {
 	to           string
 	subject      string
 	templatePath string
+	imageHosts   []string
+	client       *http.Client
 }
 
 // loginAuth implements smtp.Auth for Office365's LOGIN authentication
@@ -51,7 +59,7 @@ func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
 	return nil, nil
 }
 
-func NewEmailProvider(cfg config.EmailConfig) *EmailProvider {
+func NewEmailProvider(cfg config.EmailConfig, proxyConfig config.ProxyConfig, imageHosts []string) *EmailProvider {
 	return &EmailProvider{
 		smtpHost:     cfg.SMTPHost,
 		smtpPort:     cfg.SMTPPort,
@@ -60,6 +68,8 @@ func NewEmailProvider(cfg config.EmailConfig) *EmailProvider {
 		to:           cfg.To,
 		subject:      cfg.Subject,
 		templatePath: cfg.TemplatePath,
+		imageHosts:   imageHosts,
+		client:       utils.CreateHTTPClient(proxyConfig, cfg.UseProxy),
 	}
 }
 
@@ -89,8 +99,70 @@ func (e *EmailProvider) SendAlert(i *m.Incident) error {
 		return fmt.Errorf("failed to execute template: %w", err)
 	}
 
+	// Show the image of the alert inline, mail clients don't load remote images by default.
+	// Images that can't be downloaded from alert.image_hosts are links.
+	if image := alertImage(i); image != "" {
+		if !imageAllowed(image, e.imageHosts) {
+			fmt.Fprintf(&body, `<p><a href="%s">Alert image</a></p>`, html.EscapeString(image))
+		} else if data, imageType, err := fetchImage(e.client, image, e.imageHosts); err != nil {
+			log.Printf("Failed to attach the image of incident %s to the email: %v", i.ID, err)
+			fmt.Fprintf(&body, `<p><a href="%s">Alert image</a></p>`, html.EscapeString(image))
+		} else {
+			contentType, message, err := inlineImageMessage(body.Bytes(), data, imageType)
+			if err != nil {
+				return err
+			}
+			return e.sendMail(e.to, e.subject, contentType, message)
+		}
+	}
+
+	return e.sendMail(e.to, e.subject, "text/html; charset=UTF-8", body.Bytes())
+}
+
+// Content-ID of the inline image, referenced as cid:alert-image
+const inlineImageID = "alert-image"
+
+// inlineImageMessage builds a multipart/related message with the HTML body and the image below it
+func inlineImageMessage(html, image []byte, imageType string) (string, []byte, error) {
+	var message bytes.Buffer
+	w := multipart.NewWriter(&message)
+
+	htmlPart, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=UTF-8"}})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create email body: %w", err)
+	}
+	htmlPart.Write(html)
+	fmt.Fprintf(htmlPart, `<p><img src="cid:%s" alt="Alert image" style="max-width:100%%"></p>`, inlineImageID)
+
+	imagePart, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {imageType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-ID":                {"<" + inlineImageID + ">"},
+		"Content-Disposition":       {"inline"},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create email image: %w", err)
+	}
+
+	// Lines of base64 are limited to 76 characters in MIME
+	encoded := base64.StdEncoding.EncodeToString(image)
+	for len(encoded) > 76 {
+		fmt.Fprintf(imagePart, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(imagePart, "%s\r\n", encoded)
+
+	if err := w.Close(); err != nil {
+		return "", nil, fmt.Errorf("failed to close email body: %w", err)
+	}
+
+	return "multipart/related; boundary=" + w.Boundary(), message.Bytes(), nil
+}
+
+// sendMail delivers a message to comma-separated recipients through the configured SMTP server
+func (e *EmailProvider) sendMail(to, subject, contentType string, body []byte) error {
 	// Parse recipients (support multiple comma-separated email addresses)
-	recipients := parseRecipients(e.to)
+	recipients := parseRecipients(to)
 	if len(recipients) == 0 {
 		return fmt.Errorf("no valid email recipients found")
 	}
@@ -98,10 +170,10 @@ func (e *EmailProvider) SendAlert(i *m.Incident) error {
 	// Set email headers
 	headers := make(map[string]string)
 	headers["From"] = e.username
-	headers["To"] = e.to
-	headers["Subject"] = e.subject
+	headers["To"] = to
+	headers["Subject"] = subject
 	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
+	headers["Content-Type"] = contentType
 
 	// Construct message
 	var message bytes.Buffer
@@ -109,7 +181,7 @@ func (e *EmailProvider) SendAlert(i *m.Incident) error {
 		message.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
 	}
 	message.WriteString("\r\n")
-	message.Write(body.Bytes())
+	message.Write(body)
 
 	// Get appropriate auth based on SMTP host
 	auth := e.getAuth()
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
 	"github.com/VersusControl/versus-incident/pkg/core"
@@ -9,17 +10,31 @@ import (
 
 // Alert Provider
 type AlertProviderFactory struct {
-	cfg *config.Config
+	cfg       *config.Config
+	providers []string // Optional: providers selected by a route, instead of the enabled ones
 }
 
 func NewAlertProviderFactory(cfg *config.Config) *AlertProviderFactory {
 	return &AlertProviderFactory{cfg: cfg}
 }
 
+// WithProviders creates only the given providers, whether they are enabled or not
+func (f *AlertProviderFactory) WithProviders(providers []string) *AlertProviderFactory {
+	f.providers = providers
+	return f
+}
+
+func (f *AlertProviderFactory) use(name string, enable bool) bool {
+	if len(f.providers) > 0 {
+		return slices.Contains(f.providers, name)
+	}
+	return enable
+}
+
 func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 	var providers []core.AlertProvider
 
-	if f.cfg.Alert.Slack.Enable {
+	if f.use("slack", f.cfg.Alert.Slack.Enable) {
 		slackProvider, err := f.createSlackProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Slack provider: %w", err)
@@ -27,7 +42,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 		providers = append(providers, slackProvider)
 	}
 
-	if f.cfg.Alert.Telegram.Enable {
+	if f.use("telegram", f.cfg.Alert.Telegram.Enable) {
 		telegramProvider, err := f.createTelegramProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Telegram provider: %w", err)
@@ -35,7 +50,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 		providers = append(providers, telegramProvider)
 	}
 
-	if f.cfg.Alert.Viber.Enable {
+	if f.use("viber", f.cfg.Alert.Viber.Enable) {
 		viberProvider, err := f.createViberProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Viber provider: %w", err)
@@ -43,7 +58,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 		providers = append(providers, viberProvider)
 	}
 
-	if f.cfg.Alert.Email.Enable {
+	if f.use("email", f.cfg.Alert.Email.Enable) {
 		emailProvider, err := f.createEmailProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Email provider: %w", err)
@@ -51,7 +66,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 		providers = append(providers, emailProvider)
 	}
 
-	if f.cfg.Alert.MSTeams.Enable {
+	if f.use("msteams", f.cfg.Alert.MSTeams.Enable) {
 		msteamsProvider, err := f.createMSTeamsProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create MS Teams provider: %w", err)
@@ -59,7 +74,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 		providers = append(providers, msteamsProvider)
 	}
 
-	if f.cfg.Alert.Lark.Enable {
+	if f.use("lark", f.cfg.Alert.Lark.Enable) {
 		larkProvider, err := f.createLarkProvider()
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Lark provider: %w", err)
@@ -71,7 +86,7 @@ func (f *AlertProviderFactory) CreateProviders() ([]core.AlertProvider, error) {
 }
 
 func (f *AlertProviderFactory) createSlackProvider() (core.AlertProvider, error) {
-	sc := f.cfg.Alert.Slack
+	sc := f.cfg.Alert.Slack.Instance("")
 	if sc.Token == "" || sc.ChannelID == "" || sc.TemplatePath == "" {
 		return nil, fmt.Errorf("missing required Slack configuration")
 	}
@@ -85,7 +100,7 @@ func (f *AlertProviderFactory) createSlackProvider() (core.AlertProvider, error)
 }
 
 func (f *AlertProviderFactory) createTelegramProvider() (core.AlertProvider, error) {
-	tc := f.cfg.Alert.Telegram
+	tc := f.cfg.Alert.Telegram.Instance("")
 	if tc.BotToken == "" || tc.ChatID == "" || tc.TemplatePath == "" {
 		return nil, fmt.Errorf("missing required Telegram configuration")
 	}
@@ -95,11 +110,11 @@ func (f *AlertProviderFactory) createTelegramProvider() (core.AlertProvider, err
 		ChatID:       tc.ChatID,
 		TemplatePath: tc.TemplatePath,
 		UseProxy:     tc.UseProxy,
-	}, f.cfg.Proxy), nil
+	}, f.proxy(tc.Proxy)), nil
 }
 
 func (f *AlertProviderFactory) createViberProvider() (core.AlertProvider, error) {
-	vc := f.cfg.Alert.Viber
+	vc := f.cfg.Alert.Viber.Instance("")
 
 	// Default to channel API if not specified
 	apiType := vc.APIType
@@ -127,11 +142,11 @@ func (f *AlertProviderFactory) createViberProvider() (core.AlertProvider, error)
 		TemplatePath: vc.TemplatePath,
 		APIType:      apiType,
 		UseProxy:     vc.UseProxy,
-	}, f.cfg.Proxy), nil
+	}, f.proxy(vc.Proxy)), nil
 }
 
 func (f *AlertProviderFactory) createEmailProvider() (core.AlertProvider, error) {
-	ec := f.cfg.Alert.Email
+	ec := f.cfg.Alert.Email.Instance("")
 	if ec.SMTPHost == "" || ec.Username == "" || ec.Password == "" || ec.To == "" || ec.TemplatePath == "" {
 		return nil, fmt.Errorf("missing required Email configuration")
 	}
@@ -144,11 +159,12 @@ func (f *AlertProviderFactory) createEmailProvider() (core.AlertProvider, error)
 		To:           ec.To,
 		Subject:      ec.Subject,
 		TemplatePath: ec.TemplatePath,
-	}), nil
+		UseProxy:     ec.UseProxy,
+	}, f.proxy(ec.Proxy), f.cfg.Alert.ImageHosts), nil
 }
 
 func (f *AlertProviderFactory) createMSTeamsProvider() (core.AlertProvider, error) {
-	msc := f.cfg.Alert.MSTeams
+	msc := f.cfg.Alert.MSTeams.Instance("")
 	// Check that Power Automate URL and template path are provided
 	if msc.PowerAutomateURL == "" || msc.TemplatePath == "" {
 		return nil, fmt.Errorf("missing required MS Teams configuration: need power_automate_url and template_path")
@@ -161,7 +177,7 @@ func (f *AlertProviderFactory) createMSTeamsProvider() (core.AlertProvider, erro
 }
 
 func (f *AlertProviderFactory) createLarkProvider() (core.AlertProvider, error) {
-	lc := f.cfg.Alert.Lark
+	lc := f.cfg.Alert.Lark.Instance("")
 	// Check that webhook URL and template path are provided
 	if lc.WebhookURL == "" || lc.TemplatePath == "" {
 		return nil, fmt.Errorf("missing required Lark configuration: need webhook_url and template_path")
@@ -170,6 +186,16 @@ func (f *AlertProviderFactory) createLarkProvider() (core.AlertProvider, error)
 	return NewLarkProvider(config.LarkConfig{
 		WebhookURL:   lc.WebhookURL,
 		TemplatePath: lc.TemplatePath,
+		AppID:        lc.AppID,
+		AppSecret:    lc.AppSecret,
 		UseProxy:     lc.UseProxy,
-	}, f.cfg.Proxy), nil
+	}, f.proxy(lc.Proxy), f.cfg.Alert.ImageHosts), nil
+}
+
+// proxy returns the proxy of a named instance, or the global one
+func (f *AlertProviderFactory) proxy(p config.ProxyConfig) config.ProxyConfig {
+	if p.URL != "" {
+		return p
+	}
+	return f.cfg.Proxy
 }
@@ -5,15 +5,17 @@ import (
 
 	"github.com/VersusControl/versus-incident/pkg/config"
 	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/go-redis/redis/v8"
 )
 
 // Listener Factory
 type ListenerFactory struct {
-	cfg *config.Config
+	cfg         *config.Config
+	redisClient *redis.Client // Optional: only needed by Redis streams
 }
 
-func NewListenerFactory(cfg *config.Config) *ListenerFactory {
-	return &ListenerFactory{cfg: cfg}
+func NewListenerFactory(cfg *config.Config, redisClient *redis.Client) *ListenerFactory {
+	return &ListenerFactory{cfg: cfg, redisClient: redisClient}
 }
 
 func (f *ListenerFactory) CreateListeners() ([]core.QueueListener, error) {
@@ -35,6 +37,46 @@ func (f *ListenerFactory) CreateListeners() ([]core.QueueListener, error) {
 		listeners = append(listeners, sqsListener)
 	}
 
+	if f.cfg.Queue.Syslog.Enable {
+		syslogListener, err := f.createSyslogListener()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create syslog listener: %w", err)
+		}
+		listeners = append(listeners, syslogListener)
+	}
+
+	if f.cfg.Queue.SMTP.Enable {
+		smtpListener, err := f.createSMTPListener()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SMTP listener: %w", err)
+		}
+		listeners = append(listeners, smtpListener)
+	}
+
+	if f.cfg.Queue.Kafka.Enable {
+		kafkaListener, err := f.createKafkaListener()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kafka listener: %w", err)
+		}
+		listeners = append(listeners, kafkaListener)
+	}
+
+	if f.cfg.Queue.RedisStreams.Enable {
+		redisStreamsListener, err := f.createRedisStreamsListener()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Redis streams listener: %w", err)
+		}
+		listeners = append(listeners, redisStreamsListener)
+	}
+
+	if f.cfg.Queue.NATS.Enable {
+		natsListener, err := f.createNATSListener()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create NATS listener: %w", err)
+		}
+		listeners = append(listeners, natsListener)
+	}
+
 	if f.cfg.Queue.PubSub.Enable {
 		return nil, fmt.Errorf("GCP Pub/Sub listener not implemented")
 	}
@@ -78,3 +120,23 @@ func (f *ListenerFactory) createSQSListener() (core.QueueListener, error) {
 		QueueURL: sc.QueueURL,
 	}), nil
 }
+
+func (f *ListenerFactory) createSyslogListener() (core.QueueListener, error) {
+	return NewSyslogListener(f.cfg.Queue.Syslog)
+}
+
+func (f *ListenerFactory) createSMTPListener() (core.QueueListener, error) {
+	return NewSMTPListener(f.cfg.Queue.SMTP)
+}
+
+func (f *ListenerFactory) createKafkaListener() (core.QueueListener, error) {
+	return NewKafkaListener(f.cfg.Queue.Kafka)
+}
+
+func (f *ListenerFactory) createRedisStreamsListener() (core.QueueListener, error) {
+	return NewRedisStreamsListener(f.cfg.Queue.RedisStreams, f.redisClient)
+}
+
+func (f *ListenerFactory) createNATSListener() (core.QueueListener, error) {
+	return NewNATSListener(f.cfg.Queue.NATS)
+}
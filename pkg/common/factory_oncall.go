@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
 	"github.com/VersusControl/versus-incident/pkg/core"
@@ -21,8 +22,46 @@ func NewOnCallProviderFactory(cfg *config.Config, awsClient *ssmincidents.Client
 	}
 }
 
+// CreateProviders creates every provider listed in oncall.providers, or the single oncall.provider.
+// The providers that can't be created are skipped and reported in the error, the others are returned.
+func (f *OnCallProviderFactory) CreateProviders() ([]core.NamedOnCallProvider, error) {
+	names := f.cfg.OnCall.Providers
+	if len(names) == 0 {
+		names = []string{f.cfg.OnCall.Provider}
+	}
+
+	var (
+		providers []core.NamedOnCallProvider
+		errs      []string
+	)
+
+	for _, name := range names {
+		provider, err := f.createProvider(name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		if name == "" {
+			name = "aws_incident_manager"
+		}
+		providers = append(providers, core.NamedOnCallProvider{Name: name, Provider: provider})
+	}
+
+	if len(errs) > 0 {
+		return providers, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return providers, nil
+}
+
+// CreateProvider creates the single provider configured in oncall.provider
 func (f *OnCallProviderFactory) CreateProvider() (core.OnCallProvider, error) {
-	if f.cfg.OnCall.Provider == "aws_incident_manager" || f.cfg.OnCall.Provider == "" {
+	return f.createProvider(f.cfg.OnCall.Provider)
+}
+
+func (f *OnCallProviderFactory) createProvider(name string) (core.OnCallProvider, error) {
+	if name == "aws_incident_manager" || name == "" {
 		// Default to AWS Incident Manager for backward compatibility
 		if f.cfg.OnCall.AwsIncidentManager.ResponsePlanArn == "" {
 			return nil, fmt.Errorf("missing Response Plan ARN configuration for AWS Incident Manager")
@@ -32,26 +71,38 @@ func (f *OnCallProviderFactory) CreateProvider() (core.OnCallProvider, error) {
 			f.awsClient,
 			f.cfg.OnCall.AwsIncidentManager.ResponsePlanArn,
 		), nil
-	} else if f.cfg.OnCall.Provider == "pagerduty" {
+	} else if name == "pagerduty" {
 		if f.cfg.OnCall.PagerDuty.RoutingKey == "" {
 			return nil, fmt.Errorf("missing Routing Key configuration for PagerDuty")
 		}
 
 		return NewPagerDutyProvider(f.cfg.OnCall.PagerDuty.RoutingKey), nil
+	} else if name == "opsgenie" {
+		if f.cfg.OnCall.Opsgenie.APIKey == "" {
+			return nil, fmt.Errorf("missing API Key configuration for Opsgenie")
+		}
+
+		return NewOpsgenieProvider(f.cfg.OnCall.Opsgenie), nil
+	} else if name == "webhook" {
+		if f.cfg.OnCall.Webhook.URL == "" {
+			return nil, fmt.Errorf("missing URL configuration for on-call webhook")
+		}
+
+		return NewWebhookOnCallProvider(f.cfg.OnCall.Webhook, f.cfg.Proxy), nil
 	}
 
-	return nil, fmt.Errorf("unsupported on-call provider: %s", f.cfg.OnCall.Provider)
+	return nil, fmt.Errorf("unsupported on-call provider: %s", name)
 }
 
 // Initialize the provider factory in core
 func init() {
-	core.CreateOnCallProvider = CreateOnCallProvider
+	core.CreateOnCallProviders = CreateOnCallProviders
 }
 
-// CreateOnCallProvider is a helper function that creates an on-call provider
+// CreateOnCallProviders is a helper function that creates the on-call providers
 // This is used by the core package to create providers without directly importing
 // the implementation details
-func CreateOnCallProvider(cfg *config.Config, awsClient *ssmincidents.Client) (core.OnCallProvider, error) {
+func CreateOnCallProviders(cfg *config.Config, awsClient *ssmincidents.Client) ([]core.NamedOnCallProvider, error) {
 	factory := NewOnCallProviderFactory(cfg, awsClient)
-	return factory.CreateProvider()
+	return factory.CreateProviders()
 }
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/schedule"
+
+	"github.com/slack-go/slack"
+)
+
+// NewHandoffNotifier notifies the incoming on-call person through a Slack direct message
+// and an email, using the Slack and Email alert settings when they are enabled
+func NewHandoffNotifier(cfg *config.Config) schedule.HandoffNotifier {
+	return func(incoming *schedule.Shift, outgoing *schedule.Shift) error {
+		message := handoffMessage(incoming, outgoing)
+		slackCfg := cfg.Alert.Slack.Instance("")
+		emailCfg := cfg.Alert.Email.Instance("")
+
+		var (
+			errs []error
+			sent bool
+		)
+
+		if cfg.Alert.Slack.Enable && slackCfg.Token != "" && incoming.User.SlackUserID != "" {
+			client := slack.New(slackCfg.Token)
+
+			// Posting to a user ID opens a direct message from the bot
+			if _, _, err := client.PostMessage(incoming.User.SlackUserID, slack.MsgOptionText(message, false)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to send Slack handoff message: %w", err))
+			} else {
+				sent = true
+			}
+		}
+
+		if cfg.Alert.Email.Enable && incoming.User.Email != "" {
+			email := NewEmailProvider(emailCfg, cfg.Proxy, nil)
+			subject := fmt.Sprintf("You are now on call for %s", incoming.Schedule)
+
+			if err := email.sendMail(incoming.User.Email, subject, "text/plain; charset=UTF-8", []byte(message)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to send handoff email: %w", err))
+			} else {
+				sent = true
+			}
+		}
+
+		if !sent && len(errs) == 0 {
+			return fmt.Errorf("no Slack user ID or email to notify %s", incoming.User.Name)
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+func handoffMessage(incoming *schedule.Shift, outgoing *schedule.Shift) string {
+	message := fmt.Sprintf("You are now on call for schedule %s until %s.",
+		incoming.Schedule, incoming.End.Format(time.RFC1123))
+
+	if outgoing != nil {
+		message += fmt.Sprintf(" Taking over from %s.", outgoing.User.Name)
+	}
+
+	return message
+}
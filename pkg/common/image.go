@@ -0,0 +1,79 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Images larger than this aren't attached
+const maxImageSize = 10 << 20
+
+// alertImage returns the image of the alert, e.g. the panel image of a Grafana alert, or ""
+func alertImage(i *m.Incident) string {
+	return i.Links["image"]
+}
+
+// imageAllowed reports whether the image is on one of the hosts of alert.image_hosts. The image URL
+// comes from the payload, downloading any of them would let a payload reach internal endpoints.
+func imageAllowed(image string, hosts []string) bool {
+	u, err := url.Parse(image)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	for _, host := range hosts {
+		if strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchImage downloads the image of an alert from an allowed host, for the providers that can't
+// show it from its URL. Redirects must stay on the allowed hosts too.
+func fetchImage(client *http.Client, image string, hosts []string) ([]byte, string, error) {
+	if !imageAllowed(image, hosts) {
+		return nil, "", fmt.Errorf("image host isn't in alert.image_hosts")
+	}
+
+	restricted := *client
+	restricted.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("too many redirects")
+		}
+		if !imageAllowed(req.URL.String(), hosts) {
+			return fmt.Errorf("redirect to a host that isn't in alert.image_hosts")
+		}
+		return nil
+	}
+
+	resp, err := restricted.Get(image)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download image: status code %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("failed to download image: unexpected content type '%s'", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+
+	return data, contentType, nil
+}
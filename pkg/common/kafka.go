@@ -0,0 +1,551 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// KafkaListener consumes alert events with a consumer group. The offset of a message is
+// committed once it and the messages before it in its partition are handled.
+type KafkaListener struct {
+	groupConfig     kafka.ConsumerGroupConfig
+	readerConfig    kafka.ReaderConfig // Reader of one assigned partition, without its topic and partition
+	deadLetter      *kafka.Writer      // Nil when no dead letter topic is configured
+	groupID         string
+	topics          []string
+	deadLetterTopic string
+	concurrency     int
+	maxRetries      int
+	jsonPath        []string
+
+	mu         sync.Mutex
+	partitions map[kafkaPartitionKey]*kafkaPartition
+
+	handled      atomic.Int64
+	failed       atomic.Int64
+	skipped      atomic.Int64
+	deadLettered atomic.Int64
+}
+
+type kafkaPartitionKey struct {
+	topic     string
+	partition int
+}
+
+// kafkaPartition tracks the messages in flight of an assigned partition, in the order they were
+// fetched. Each partition is fetched by its own reader, so a slow partition doesn't hold back the others.
+type kafkaPartition struct {
+	generation *kafka.Generation
+	slots      chan struct{} // Limits the messages handled at once, fetching waits for a free slot
+	halt       chan struct{} // Closed when the partition stops, its fetching pauses
+
+	mu            sync.Mutex
+	inFlight      []kafka.Message
+	done          map[int64]bool
+	committed     int64 // Next offset to consume, as committed to the group
+	highWaterMark int64
+	stopped       string // Why the partition stopped, empty while it runs
+}
+
+// KafkaPartitionStatus is the progress of the group on a partition
+type KafkaPartitionStatus struct {
+	Topic           string `json:"topic"`
+	Partition       int    `json:"partition"`
+	CommittedOffset int64  `json:"committed_offset"`
+	HighWaterMark   int64  `json:"high_water_mark"`
+	Lag             int64  `json:"lag"`
+	InFlight        int    `json:"in_flight"`
+	Stopped         string `json:"stopped,omitempty"`
+}
+
+func NewKafkaListener(cfg config.KafkaConfig) (*KafkaListener, error) {
+	brokers := splitKafkaList(cfg.Brokers)
+	topics := splitKafkaList(cfg.Topics)
+	if len(brokers) == 0 || len(topics) == 0 {
+		return nil, fmt.Errorf("missing kafka brokers or topics configuration")
+	}
+
+	l := &KafkaListener{
+		groupID:         cfg.GroupID,
+		topics:          topics,
+		deadLetterTopic: cfg.DeadLetterTopic,
+		concurrency:     cfg.Concurrency,
+		maxRetries:      cfg.MaxRetries,
+		partitions:      make(map[kafkaPartitionKey]*kafkaPartition),
+	}
+
+	if l.groupID == "" {
+		l.groupID = "versus-incident"
+	}
+	if l.concurrency <= 0 {
+		l.concurrency = 1
+	}
+	if l.maxRetries <= 0 {
+		l.maxRetries = 3
+	}
+
+	if path := strings.TrimPrefix(strings.TrimPrefix(cfg.JSONPath, "$"), "."); path != "" {
+		l.jsonPath = strings.Split(path, ".")
+	}
+
+	startOffset := kafka.LastOffset
+	switch cfg.StartOffset {
+	case "", "latest":
+	case "earliest":
+		startOffset = kafka.FirstOffset
+	default:
+		return nil, fmt.Errorf("invalid kafka start offset '%s', expected latest or earliest", cfg.StartOffset)
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+
+	if cfg.TLS.Enable {
+		tlsConfig, err := kafkaTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLS = tlsConfig
+	}
+
+	if cfg.SASL.Mechanism != "" {
+		mechanism, err := kafkaSASLMechanism(cfg.SASL)
+		if err != nil {
+			return nil, err
+		}
+		dialer.SASLMechanism = mechanism
+	}
+
+	errorLogger := kafka.LoggerFunc(func(msg string, args ...interface{}) {
+		log.Printf("Kafka: "+msg, args...)
+	})
+
+	l.groupConfig = kafka.ConsumerGroupConfig{
+		ID:          l.groupID,
+		Brokers:     brokers,
+		Topics:      topics,
+		Dialer:      dialer,
+		StartOffset: startOffset,
+		ErrorLogger: errorLogger,
+	}
+	if err := l.groupConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka consumer group configuration: %w", err)
+	}
+
+	l.readerConfig = kafka.ReaderConfig{
+		Brokers:     brokers,
+		Dialer:      dialer,
+		MaxBytes:    10 << 20,
+		ErrorLogger: errorLogger,
+	}
+
+	if l.deadLetterTopic != "" {
+		l.deadLetter = &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        l.deadLetterTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			Transport: &kafka.Transport{
+				DialTimeout: dialer.Timeout,
+				TLS:         dialer.TLS,
+				SASL:        dialer.SASLMechanism,
+			},
+		}
+	}
+
+	return l, nil
+}
+
+// splitKafkaList accepts lists and comma-separated entries, e.g. from ${KAFKA_BROKERS}
+func splitKafkaList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func kafkaTLSConfig(cfg config.KafkaTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kafka CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to append kafka CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func kafkaSASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.Mechanism) {
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	}
+	return nil, fmt.Errorf("invalid kafka SASL mechanism '%s', expected plain, scram-sha-256 or scram-sha-512", cfg.Mechanism)
+}
+
+// StartListening consumes the partitions assigned to the listener until the group fails.
+// Each partition is fetched by its own reader, and handled up to the configured concurrency.
+func (l *KafkaListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	group, err := kafka.NewConsumerGroup(l.groupConfig)
+	if err != nil {
+		return fmt.Errorf("kafka listener: %w", err)
+	}
+	defer group.Close()
+	if l.deadLetter != nil {
+		defer l.deadLetter.Close()
+	}
+
+	log.Printf("Kafka listener started for topics %s with group %s", strings.Join(l.topics, ", "), l.groupID)
+
+	ctx := context.Background()
+	for {
+		generation, err := group.Next(ctx)
+		if err != nil {
+			return fmt.Errorf("kafka listener: %w", err)
+		}
+
+		// A rebalance starts over from the committed offsets of the new assignment
+		partitions := make(map[kafkaPartitionKey]*kafkaPartition)
+		for topic, assignments := range generation.Assignments {
+			for _, assignment := range assignments {
+				p := &kafkaPartition{
+					generation: generation,
+					slots:      make(chan struct{}, l.concurrency),
+					halt:       make(chan struct{}),
+					done:       make(map[int64]bool),
+					committed:  assignment.Offset,
+				}
+				partitions[kafkaPartitionKey{topic: topic, partition: assignment.ID}] = p
+
+				generation.Start(func(ctx context.Context) {
+					l.consume(ctx, p, topic, assignment, handler)
+				})
+			}
+		}
+
+		l.mu.Lock()
+		l.partitions = partitions
+		l.mu.Unlock()
+	}
+}
+
+// consume fetches the messages of an assigned partition until the generation ends. Fetching waits
+// for a free slot of the concurrency, so only the messages in flight are kept in memory. A stopped
+// partition stops fetching, but holds its assignment: the generation ends when one of its partitions
+// returns, and the other partitions would be rebalanced with it.
+func (l *KafkaListener) consume(ctx context.Context, p *kafkaPartition, topic string, assignment kafka.PartitionAssignment, handler func(content *map[string]interface{}) error) {
+	cfg := l.readerConfig
+	cfg.Topic = topic
+	cfg.Partition = assignment.ID
+
+	reader := kafka.NewReader(cfg)
+	defer reader.Close()
+
+	if err := reader.SetOffset(assignment.Offset); err != nil {
+		log.Printf("Failed to seek kafka partition %s/%d to offset %d: %v", topic, assignment.ID, assignment.Offset, err)
+		<-ctx.Done()
+		return
+	}
+
+	for {
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.halt:
+			reader.Close()
+			<-ctx.Done()
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			<-p.slots
+			if ctx.Err() == nil {
+				log.Printf("Failed to fetch kafka partition %s/%d: %v", topic, assignment.ID, err)
+				<-ctx.Done()
+			}
+			return
+		}
+
+		if !p.start(msg) {
+			<-p.slots
+			continue
+		}
+
+		go func() {
+			defer func() { <-p.slots }()
+
+			if err := l.handle(ctx, msg, handler); err != nil {
+				// The generation ended during a backoff, the message is fetched again by the next one
+				if ctx.Err() != nil {
+					return
+				}
+				l.fail(ctx, p, msg, err)
+				return
+			}
+			l.commit(p, msg)
+		}()
+	}
+}
+
+// start moves the message in flight, it returns false when the partition stopped meanwhile
+func (p *kafkaPartition) start(msg kafka.Message) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if msg.HighWaterMark > p.highWaterMark {
+		p.highWaterMark = msg.HighWaterMark
+	}
+	// The first offset of a partition without a committed offset is only known once fetched
+	if p.committed < 0 {
+		p.committed = msg.Offset
+	}
+	if p.stopped != "" {
+		return false
+	}
+
+	p.inFlight = append(p.inFlight, msg)
+	return true
+}
+
+// handle retries a failed message with a backoff, and returns the last error after the retries.
+// Messages without an incident are skipped. The backoff stops when ctx is done.
+func (l *KafkaListener) handle(ctx context.Context, msg kafka.Message, handler func(content *map[string]interface{}) error) error {
+	content, err := l.content(msg.Value)
+	if err != nil {
+		l.skipped.Add(1)
+		log.Printf("Skipped kafka message %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		return nil
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		if err = handler(&content); err == nil {
+			l.handled.Add(1)
+			return nil
+		}
+
+		l.failed.Add(1)
+		if attempt >= l.maxRetries {
+			return fmt.Errorf("failed after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Printf("Failed to handle kafka message %s/%d@%d, retrying in %s: %v", msg.Topic, msg.Partition, msg.Offset, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// fail publishes a message that failed every retry to the dead letter topic, then commits it.
+// Without a dead letter topic, or when the publish fails, the partition stops: its offset
+// stays before the message, so the message is handled again after a restart.
+func (l *KafkaListener) fail(ctx context.Context, p *kafkaPartition, msg kafka.Message, err error) {
+	if l.deadLetter != nil {
+		headers := append([]kafka.Header{}, msg.Headers...)
+		headers = append(headers,
+			kafka.Header{Key: "versus-topic", Value: []byte(msg.Topic)},
+			kafka.Header{Key: "versus-partition", Value: []byte(strconv.Itoa(msg.Partition))},
+			kafka.Header{Key: "versus-offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			kafka.Header{Key: "versus-error", Value: []byte(err.Error())},
+		)
+
+		publishErr := l.deadLetter.WriteMessages(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers})
+		if publishErr == nil {
+			l.deadLettered.Add(1)
+			log.Printf("Moved kafka message %s/%d@%d to %s: %v", msg.Topic, msg.Partition, msg.Offset, l.deadLetterTopic, err)
+			l.commit(p, msg)
+			return
+		}
+		err = fmt.Errorf("%w, and failed to publish it to %s: %v", err, l.deadLetterTopic, publishErr)
+	}
+
+	p.stop(fmt.Sprintf("offset %d: %v", msg.Offset, err))
+	log.Printf("Stopped kafka partition %s/%d at offset %d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+}
+
+// stop pauses the fetching of the partition, its offset stays before the failed message
+func (p *kafkaPartition) stop(reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped != "" {
+		return
+	}
+	p.stopped = reason
+	close(p.halt)
+}
+
+// content decodes the message and picks the incident out of its envelope.
+// An incident that is itself a JSON string, e.g. {"Message": "{...}"}, is decoded too.
+func (l *KafkaListener) content(value []byte) (map[string]interface{}, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	for _, key := range l.jsonPath {
+		switch v := decoded.(type) {
+		case map[string]interface{}:
+			decoded = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("no incident at %s", strings.Join(l.jsonPath, "."))
+			}
+			decoded = v[i]
+		default:
+			return nil, fmt.Errorf("no incident at %s", strings.Join(l.jsonPath, "."))
+		}
+	}
+
+	if decoded == nil {
+		return nil, fmt.Errorf("no incident at %s", strings.Join(l.jsonPath, "."))
+	}
+
+	if s, ok := decoded.(string); ok {
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, fmt.Errorf("invalid JSON at %s: %w", strings.Join(l.jsonPath, "."), err)
+		}
+	}
+
+	content, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the incident is not a JSON object")
+	}
+	return content, nil
+}
+
+// commit commits the offset of the messages handled in a row from the oldest one in flight,
+// so a restart never skips a message that wasn't handled. After a rebalance the commit fails,
+// and the new owner of the partition handles the message again.
+func (l *KafkaListener) commit(p *kafkaPartition, msg kafka.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	last := p.complete(msg)
+	if last == nil {
+		return
+	}
+
+	offsets := map[string]map[int]int64{last.Topic: {last.Partition: last.Offset + 1}}
+	if err := p.generation.CommitOffsets(offsets); err != nil {
+		log.Printf("Failed to commit kafka offset %s/%d@%d: %v", last.Topic, last.Partition, last.Offset, err)
+		return
+	}
+	p.committed = last.Offset + 1
+}
+
+// complete marks the message as done and returns the last message that can be committed,
+// or nil when an older message is still in flight. The caller holds the lock.
+func (p *kafkaPartition) complete(msg kafka.Message) *kafka.Message {
+	p.done[msg.Offset] = true
+
+	var last *kafka.Message
+	for len(p.inFlight) > 0 && p.done[p.inFlight[0].Offset] {
+		delete(p.done, p.inFlight[0].Offset)
+		last = &p.inFlight[0]
+		p.inFlight = p.inFlight[1:]
+	}
+	return last
+}
+
+// QueueStatus reports the lag of the group per assigned partition, from the high water mark of the last fetch,
+// and the partitions stopped on a message that failed
+func (l *KafkaListener) QueueStatus() map[string]interface{} {
+	l.mu.Lock()
+	partitions := make([]KafkaPartitionStatus, 0, len(l.partitions))
+	for key, p := range l.partitions {
+		p.mu.Lock()
+		status := KafkaPartitionStatus{
+			Topic:           key.topic,
+			Partition:       key.partition,
+			CommittedOffset: p.committed,
+			HighWaterMark:   p.highWaterMark,
+			InFlight:        len(p.inFlight),
+			Stopped:         p.stopped,
+		}
+		// Nothing was fetched yet from a partition without a committed offset
+		if p.committed >= 0 {
+			status.Lag = max(p.highWaterMark-p.committed, 0)
+		}
+		p.mu.Unlock()
+		partitions = append(partitions, status)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Topic != partitions[j].Topic {
+			return partitions[i].Topic < partitions[j].Topic
+		}
+		return partitions[i].Partition < partitions[j].Partition
+	})
+
+	var lag int64
+	for _, p := range partitions {
+		lag += p.Lag
+	}
+
+	return map[string]interface{}{
+		"type":          "kafka",
+		"group_id":      l.groupID,
+		"topics":        l.topics,
+		"lag":           lag,
+		"partitions":    partitions,
+		"handled":       l.handled.Load(),
+		"failed":        l.failed.Load(),
+		"skipped":       l.skipped.Load(),
+		"dead_lettered": l.deadLettered.Load(),
+	}
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestKafkaPartitionComplete(t *testing.T) {
+	tests := []struct {
+		name      string
+		fetched   []int64
+		completed []int64
+		want      []int64 // Offset to commit after each completion, -1 for none
+		wantLeft  int
+	}{
+		{"in order", []int64{1, 2, 3}, []int64{1, 2, 3}, []int64{1, 2, 3}, 0},
+		{"newest first", []int64{1, 2, 3}, []int64{3, 2, 1}, []int64{-1, -1, 3}, 0},
+		{"gap", []int64{1, 2, 3, 4}, []int64{2, 4, 1}, []int64{-1, -1, 2}, 2},
+		{"gap filled", []int64{1, 2, 3, 4}, []int64{2, 4, 1, 3}, []int64{-1, -1, 2, 4}, 0},
+		{"offsets skipped by compaction", []int64{10, 15, 40}, []int64{15, 10, 40}, []int64{-1, 15, 40}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &kafkaPartition{done: make(map[int64]bool), committed: -1}
+			for _, offset := range tt.fetched {
+				if !p.start(kafka.Message{Offset: offset, HighWaterMark: offset + 1}) {
+					t.Fatalf("start(%d) failed", offset)
+				}
+			}
+
+			var got []int64
+			for _, offset := range tt.completed {
+				last := p.complete(kafka.Message{Offset: offset})
+				if last == nil {
+					got = append(got, -1)
+				} else {
+					got = append(got, last.Offset)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("complete() = %v, want %v", got, tt.want)
+			}
+			if len(p.inFlight) != tt.wantLeft {
+				t.Errorf("%d messages in flight, want %d", len(p.inFlight), tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestKafkaPartitionStart(t *testing.T) {
+	p := &kafkaPartition{done: make(map[int64]bool), committed: -1, halt: make(chan struct{})}
+
+	p.start(kafka.Message{Offset: 7, HighWaterMark: 20})
+	if p.committed != 7 || p.highWaterMark != 20 {
+		t.Errorf("committed = %d, high water mark = %d, want 7, 20", p.committed, p.highWaterMark)
+	}
+
+	p.stop("offset 7: failed")
+	p.stop("offset 8: failed") // The first reason is kept
+	if p.start(kafka.Message{Offset: 8, HighWaterMark: 20}) {
+		t.Error("start() succeeded on a stopped partition")
+	}
+	if p.stopped != "offset 7: failed" || len(p.inFlight) != 1 {
+		t.Errorf("stopped = %q with %d messages in flight", p.stopped, len(p.inFlight))
+	}
+}
+
+func TestKafkaContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonPath []string
+		value    string
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{"object", nil, `{"title":"disk full"}`, map[string]interface{}{"title": "disk full"}, false},
+		{"nested", []string{"detail", "alerts", "0"}, `{"detail":{"alerts":[{"title":"disk full"}]}}`, map[string]interface{}{"title": "disk full"}, false},
+		{"encoded string", []string{"Message"}, `{"Message":"{\"title\":\"disk full\"}"}`, map[string]interface{}{"title": "disk full"}, false},
+		{"missing path", []string{"detail"}, `{"title":"disk full"}`, nil, true},
+		{"index out of range", []string{"1"}, `[{"title":"disk full"}]`, nil, true},
+		{"not an object", nil, `["disk full"]`, nil, true},
+		{"invalid JSON", nil, `{`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &KafkaListener{jsonPath: tt.jsonPath}
+			got, err := l.content([]byte(tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("content() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("content() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKafkaHandle(t *testing.T) {
+	msg := kafka.Message{Value: []byte(`{"title":"disk full"}`)}
+	failing := func(content *map[string]interface{}) error { return errors.New("unavailable") }
+
+	l := &KafkaListener{maxRetries: 0}
+	if err := l.handle(context.Background(), msg, failing); err == nil {
+		t.Error("handle() without retries succeeded")
+	}
+
+	// The backoff returns at once when the generation ends
+	l.maxRetries = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.handle(ctx, msg, failing); !errors.Is(err, context.Canceled) {
+		t.Errorf("handle() with a done context = %v, want %v", err, context.Canceled)
+	}
+
+	// Messages without an incident are skipped
+	if err := l.handle(context.Background(), kafka.Message{Value: []byte("not json")}, failing); err != nil || l.skipped.Load() != 1 {
+		t.Errorf("handle() of an invalid message = %v with %d skipped", err, l.skipped.Load())
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"text/template"
 
@@ -17,14 +20,20 @@ import (
 type LarkProvider struct {
 	webhookURL   string
 	templatePath string
+	appID        string
+	appSecret    string
+	imageHosts   []string
 	client       *http.Client
 }
 
-func NewLarkProvider(cfg config.LarkConfig, proxyConfig config.ProxyConfig) *LarkProvider {
+func NewLarkProvider(cfg config.LarkConfig, proxyConfig config.ProxyConfig, imageHosts []string) *LarkProvider {
 	client := utils.CreateHTTPClient(proxyConfig, cfg.UseProxy)
 	return &LarkProvider{
 		webhookURL:   cfg.WebhookURL,
 		templatePath: cfg.TemplatePath,
+		appID:        cfg.AppID,
+		appSecret:    cfg.AppSecret,
+		imageHosts:   imageHosts,
 		client:       client,
 	}
 }
@@ -45,6 +54,10 @@ func (l *LarkProvider) SendAlert(i *m.Incident) error {
 	// Create interactive card message
 	larkMsg := utils.CreateLarkMessage(message.String(), i.Resolved)
 
+	if image := alertImage(i); image != "" {
+		larkMsg.Card.Elements = append(larkMsg.Card.Elements, l.imageElement(i, image))
+	}
+
 	jsonData, err := json.Marshal(larkMsg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
@@ -70,3 +83,101 @@ func (l *LarkProvider) SendAlert(i *m.Incident) error {
 
 	return nil
 }
+
+// imageElement shows the image of the alert. Cards only show uploaded images, which needs
+// the credentials of an app and an image on alert.image_hosts, otherwise the image is a link.
+func (l *LarkProvider) imageElement(i *m.Incident, image string) utils.LarkCardElement {
+	link := utils.LarkCardElement{Tag: "markdown", Content: fmt.Sprintf("[🖼️ Alert image](%s)", image)}
+
+	if l.appID == "" || l.appSecret == "" || !imageAllowed(image, l.imageHosts) {
+		return link
+	}
+
+	key, err := l.uploadImage(image)
+	if err != nil {
+		log.Printf("Failed to upload the image of incident %s to Lark: %v", i.ID, err)
+		return link
+	}
+
+	return utils.LarkCardElement{
+		Tag:    "img",
+		ImgKey: key,
+		Alt:    &utils.LarkCardTitle{Tag: "plain_text", Content: i.Title},
+	}
+}
+
+// larkResponse is the part of the Open API responses used to upload images
+type larkResponse struct {
+	Code              int    `json:"code"`
+	Msg               string `json:"msg"`
+	TenantAccessToken string `json:"tenant_access_token"`
+	Data              struct {
+		ImageKey string `json:"image_key"`
+	} `json:"data"`
+}
+
+// uploadImage downloads the image and uploads it to Lark, it returns the key of the uploaded image
+func (l *LarkProvider) uploadImage(image string) (string, error) {
+	data, _, err := fetchImage(l.client, image, l.imageHosts)
+	if err != nil {
+		return "", err
+	}
+
+	// The Open API is on the host of the webhook, Lark or Feishu
+	webhook, err := url.Parse(l.webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	api := webhook.Scheme + "://" + webhook.Host + "/open-apis"
+
+	credentials, _ := json.Marshal(map[string]string{"app_id": l.appID, "app_secret": l.appSecret})
+	token, err := l.call(api+"/auth/v3/tenant_access_token/internal", "application/json", "", bytes.NewBuffer(credentials))
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	w.WriteField("image_type", "message")
+	part, err := w.CreateFormFile("image", "image")
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload: %w", err)
+	}
+	part.Write(data)
+	w.Close()
+
+	uploaded, err := l.call(api+"/im/v1/images", w.FormDataContentType(), token.TenantAccessToken, &form)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	return uploaded.Data.ImageKey, nil
+}
+
+// call posts to the Open API and checks the code of the response
+func (l *LarkProvider) call(endpoint, contentType, token string, body io.Reader) (*larkResponse, error) {
+	req, err := http.NewRequest("POST", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result larkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid response, status code %d: %w", resp.StatusCode, err)
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("lark API returned code %d: %s", result.Code, result.Msg)
+	}
+
+	return &result, nil
+}
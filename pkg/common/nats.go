@@ -0,0 +1,317 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Delay before a failed message is delivered again, multiplied by its deliveries
+const natsRetryDelay = 5 * time.Second
+
+// NATSListener consumes alert events from a JetStream stream with a durable pull consumer.
+// A message is acknowledged once its incident is created, a failed message is delivered
+// again until max_deliver, then it is published to the dead-letter subject.
+type NATSListener struct {
+	url               string
+	stream            string
+	consumerConfig    jetstream.ConsumerConfig
+	deadLetterSubject string
+	maxDeliver        int // Deliveries before a failing message is dead-lettered
+	options           []nats.Option
+
+	mu       sync.Mutex
+	js       jetstream.JetStream
+	consumer jetstream.Consumer
+
+	handled      atomic.Int64
+	failed       atomic.Int64
+	deadLettered atomic.Int64
+}
+
+func NewNATSListener(cfg config.NATSConfig) (*NATSListener, error) {
+	if cfg.Stream == "" {
+		return nil, fmt.Errorf("missing NATS stream configuration")
+	}
+
+	l := &NATSListener{
+		url:               cfg.URL,
+		stream:            cfg.Stream,
+		deadLetterSubject: cfg.DeadLetterSubject,
+		maxDeliver:        cfg.MaxDeliver,
+		options:           []nats.Option{nats.Name("versus-incident"), nats.MaxReconnects(-1)},
+	}
+
+	if l.url == "" {
+		l.url = nats.DefaultURL
+	}
+
+	// The server delivers without limit: the listener dead-letters a message after max_deliver,
+	// and a message that couldn't be dead-lettered must still be delivered again
+	cc := jetstream.ConsumerConfig{
+		Durable:       cfg.Consumer,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		AckWait:       30 * time.Second,
+		MaxDeliver:    -1,
+	}
+
+	if cc.Durable == "" {
+		cc.Durable = "versus-incident"
+	}
+	if l.maxDeliver <= 0 {
+		l.maxDeliver = 5
+	}
+
+	switch len(cfg.Subjects) {
+	case 0:
+	case 1:
+		cc.FilterSubject = cfg.Subjects[0]
+	default:
+		cc.FilterSubjects = cfg.Subjects
+	}
+
+	switch cfg.DeliverPolicy {
+	case "", "new":
+	case "all":
+		cc.DeliverPolicy = jetstream.DeliverAllPolicy
+	default:
+		return nil, fmt.Errorf("invalid NATS deliver policy '%s', expected new or all", cfg.DeliverPolicy)
+	}
+
+	if cfg.AckWait != "" {
+		ackWait, err := time.ParseDuration(cfg.AckWait)
+		if err != nil || ackWait <= 0 {
+			return nil, fmt.Errorf("invalid NATS ack_wait '%s'", cfg.AckWait)
+		}
+		cc.AckWait = ackWait
+	}
+	l.consumerConfig = cc
+
+	switch {
+	case cfg.CredsFile != "":
+		l.options = append(l.options, nats.UserCredentials(cfg.CredsFile))
+	case cfg.Token != "":
+		l.options = append(l.options, nats.Token(cfg.Token))
+	case cfg.Username != "":
+		l.options = append(l.options, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+
+	if cfg.TLS.Enable {
+		tlsConfig, err := natsTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		l.options = append(l.options, nats.Secure(tlsConfig))
+	}
+
+	return l, nil
+}
+
+func natsTLSConfig(cfg config.NATSTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NATS CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to append NATS CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load NATS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// StartListening creates or updates the durable consumer, then handles its messages one by one
+// until the consumer is deleted or the connection is closed
+func (l *NATSListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	conn, err := nats.Connect(l.url, l.options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	defer conn.Close()
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return fmt.Errorf("failed to create the JetStream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	consumer, err := js.CreateOrUpdateConsumer(ctx, l.stream, l.consumerConfig)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create the NATS consumer %s of stream %s: %w", l.consumerConfig.Durable, l.stream, err)
+	}
+
+	l.mu.Lock()
+	l.js, l.consumer = js, consumer
+	l.mu.Unlock()
+
+	// Messages are handled one at a time, a larger pull buffer would wait past ack_wait and be redelivered
+	messages, err := consumer.Messages(jetstream.PullMaxMessages(1))
+	if err != nil {
+		return fmt.Errorf("failed to consume NATS messages: %w", err)
+	}
+	defer messages.Stop()
+
+	log.Printf("NATS listener started for stream %s with consumer %s", l.stream, l.consumerConfig.Durable)
+
+	for {
+		msg, err := messages.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) || errors.Is(err, jetstream.ErrConsumerDeleted) {
+				return fmt.Errorf("NATS listener: %w", err)
+			}
+
+			// Missed heartbeats while the server is away, the iterator keeps pulling
+			log.Printf("NATS listener: %v", err)
+			continue
+		}
+
+		l.handle(msg, handler)
+	}
+}
+
+// handle acknowledges the message once its incident is created. A failed message is delivered
+// again with a growing delay, the last delivery and the messages that aren't JSON objects
+// are dead-lettered instead.
+func (l *NATSListener) handle(msg jetstream.Msg, handler func(content *map[string]interface{}) error) {
+	var deliveries uint64 = 1
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = meta.NumDelivered
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(msg.Data(), &content); err != nil || content == nil {
+		l.deadLetter(msg, deliveries, fmt.Errorf("not a JSON object"))
+		return
+	}
+
+	if err := handler(&content); err != nil {
+		l.failed.Add(1)
+
+		if deliveries >= uint64(l.maxDeliver) {
+			l.deadLetter(msg, deliveries, err)
+			return
+		}
+
+		delay := time.Duration(deliveries) * natsRetryDelay
+		log.Printf("Failed to handle NATS message %s, retrying in %s: %v", msg.Subject(), delay, err)
+		if err := msg.NakWithDelay(delay); err != nil {
+			log.Printf("Failed to nak NATS message %s: %v", msg.Subject(), err)
+		}
+		return
+	}
+
+	l.handled.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := msg.DoubleAck(ctx); err != nil {
+		log.Printf("Failed to ack NATS message %s, it may be delivered again: %v", msg.Subject(), err)
+	}
+}
+
+// deadLetter publishes the message to the dead-letter subject, with the reason in the headers,
+// and terminates it once the stream of the subject acknowledged it. When the publish fails,
+// the message is delivered again later. Without a dead-letter subject it is only logged.
+func (l *NATSListener) deadLetter(msg jetstream.Msg, deliveries uint64, reason error) {
+	if l.deadLetterSubject == "" {
+		log.Printf("Dropped NATS message %s after %d deliveries: %v", msg.Subject(), deliveries, reason)
+	} else {
+		dead := nats.NewMsg(l.deadLetterSubject)
+		dead.Data = msg.Data()
+		for k, v := range msg.Headers() {
+			dead.Header[k] = v
+		}
+		dead.Header.Set("Versus-Subject", msg.Subject())
+		dead.Header.Set("Versus-Stream", l.stream)
+		dead.Header.Set("Versus-Deliveries", strconv.FormatUint(deliveries, 10))
+		dead.Header.Set("Versus-Error", reason.Error())
+
+		l.mu.Lock()
+		js := l.js
+		l.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := js.PublishMsg(ctx, dead)
+		cancel()
+		if err != nil {
+			delay := time.Duration(deliveries) * natsRetryDelay
+			log.Printf("Failed to dead-letter NATS message %s, retrying in %s: %v", msg.Subject(), delay, err)
+			if err := msg.NakWithDelay(delay); err != nil {
+				log.Printf("Failed to nak NATS message %s: %v", msg.Subject(), err)
+			}
+			return
+		}
+		log.Printf("Dead-lettered NATS message %s to %s after %d deliveries: %v", msg.Subject(), l.deadLetterSubject, deliveries, reason)
+	}
+
+	l.deadLettered.Add(1)
+	if err := msg.TermWithReason(reason.Error()); err != nil {
+		log.Printf("Failed to terminate NATS message %s: %v", msg.Subject(), err)
+	}
+}
+
+// QueueStatus reports the messages of the consumer not delivered yet and waiting for an ack
+func (l *NATSListener) QueueStatus() map[string]interface{} {
+	status := map[string]interface{}{
+		"type":          "nats",
+		"stream":        l.stream,
+		"consumer":      l.consumerConfig.Durable,
+		"handled":       l.handled.Load(),
+		"failed":        l.failed.Load(),
+		"dead_lettered": l.deadLettered.Load(),
+	}
+
+	l.mu.Lock()
+	consumer := l.consumer
+	l.mu.Unlock()
+
+	if consumer == nil {
+		status["error"] = "not connected"
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		status["error"] = err.Error()
+		return status
+	}
+
+	status["pending"] = info.NumPending
+	status["ack_pending"] = info.NumAckPending
+	status["redelivered"] = info.NumRedelivered
+
+	return status
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// incidentSummary picks a human readable title from common payload fields
+func incidentSummary(incident *m.Incident) string {
+	if incident.Content != nil {
+		content := *incident.Content
+
+		for _, field := range []string{"title", "summary", "message", "AlarmName"} {
+			if val, ok := content[field].(string); ok && val != "" {
+				return val
+			}
+		}
+
+		if labels, ok := content["commonLabels"].(map[string]interface{}); ok {
+			if val, ok := labels["alertname"].(string); ok && val != "" {
+				return val
+			}
+		}
+	}
+
+	return incident.ID
+}
+
+// incidentSeverity looks up the severity in common payload fields
+func incidentSeverity(incident *m.Incident) string {
+	if incident.Content == nil {
+		return ""
+	}
+
+	content := *incident.Content
+
+	for _, field := range []string{"severity", "level", "priority"} {
+		if val, ok := content[field].(string); ok && val != "" {
+			return val
+		}
+	}
+
+	for _, field := range []string{"commonLabels", "labels"} {
+		if labels, ok := content[field].(map[string]interface{}); ok {
+			if val, ok := labels["severity"].(string); ok && val != "" {
+				return val
+			}
+		}
+	}
+
+	return ""
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,199 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+const (
+	opsgenieAPIURL   = "https://api.opsgenie.com"
+	opsgenieEUAPIURL = "https://api.eu.opsgenie.com"
+
+	opsgenieMaxMessageLength = 130 // Opsgenie rejects longer alert messages
+)
+
+// Default severity -> priority mapping, can be extended with priority_mapping
+var opsgenieDefaultPriorities = map[string]string{
+	"critical": "P1",
+	"fatal":    "P1",
+	"high":     "P2",
+	"error":    "P2",
+	"warning":  "P3",
+	"medium":   "P3",
+	"low":      "P4",
+	"info":     "P5",
+}
+
+// Opsgenie Alerts API payload structures
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// OpsgenieCloseAlert is the body for both the close and acknowledge actions
+type OpsgenieCloseAlert struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// OpsgenieProvider implements the OnCallProvider interface for Opsgenie
+type OpsgenieProvider struct {
+	apiKey          string
+	apiURL          string
+	priorityMapping map[string]string
+	httpClient      *http.Client
+}
+
+// NewOpsgenieProvider creates a new Opsgenie provider
+func NewOpsgenieProvider(cfg config.OpsgenieConfig) *OpsgenieProvider {
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = opsgenieAPIURL
+		if strings.EqualFold(cfg.Region, "eu") {
+			apiURL = opsgenieEUAPIURL
+		}
+	}
+
+	priorityMapping := make(map[string]string)
+	for severity, priority := range opsgenieDefaultPriorities {
+		priorityMapping[severity] = priority
+	}
+	for severity, priority := range cfg.PriorityMapping {
+		priorityMapping[strings.ToLower(severity)] = strings.ToUpper(priority)
+	}
+
+	return &OpsgenieProvider{
+		apiKey:          cfg.APIKey,
+		apiURL:          strings.TrimRight(apiURL, "/"),
+		priorityMapping: priorityMapping,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// TriggerOnCall creates an alert in Opsgenie, reusing the incident fingerprint as alias
+// so repeated notifications of the same alert are deduplicated
+func (p *OpsgenieProvider) TriggerOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	alert := OpsgenieAlert{
+		Message:  truncate("Incident "+incidentSummary(incident), opsgenieMaxMessageLength),
+		Alias:    p.alias(incident),
+		Source:   "Versus Incident",
+		Priority: p.priority(incidentSeverity(incident)),
+		Details: map[string]string{
+			"incident_id": incident.ID,
+		},
+	}
+
+	if incident.Content != nil {
+		if description, err := json.MarshalIndent(*incident.Content, "", "  "); err == nil {
+			alert.Description = string(description)
+		}
+	}
+
+	if err := p.send(ctx, p.resolveAPIKey(cfg), "/v2/alerts", alert); err != nil {
+		return err
+	}
+
+	log.Printf("Opsgenie alert escalated: %s", incident.ID)
+	return nil
+}
+
+// ResolveOnCall closes the Opsgenie alert that was created for the same fingerprint
+func (p *OpsgenieProvider) ResolveOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	path := fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(p.alias(incident)))
+
+	closeAlert := OpsgenieCloseAlert{
+		Source: "Versus Incident",
+		Note:   "Resolved notification received by Versus Incident",
+	}
+
+	if err := p.send(ctx, p.resolveAPIKey(cfg), path, closeAlert); err != nil {
+		return err
+	}
+
+	log.Printf("Opsgenie alert closed: %s", p.alias(incident))
+	return nil
+}
+
+// AckOnCall acknowledges the Opsgenie alert after the incident was acknowledged in Versus
+func (p *OpsgenieProvider) AckOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	path := fmt.Sprintf("/v2/alerts/%s/acknowledge?identifierType=alias", url.PathEscape(p.alias(incident)))
+
+	ackAlert := OpsgenieCloseAlert{
+		Source: "Versus Incident",
+		Note:   "Acknowledged in Versus Incident",
+	}
+
+	if err := p.send(ctx, p.resolveAPIKey(cfg), path, ackAlert); err != nil {
+		return err
+	}
+
+	log.Printf("Opsgenie alert acknowledged: %s", p.alias(incident))
+	return nil
+}
+
+// resolveAPIKey uses the override config if provided, otherwise the default
+func (p *OpsgenieProvider) resolveAPIKey(cfg *config.OnCallConfig) string {
+	if cfg != nil && cfg.Opsgenie.APIKey != "" {
+		return cfg.Opsgenie.APIKey
+	}
+	return p.apiKey
+}
+
+func (p *OpsgenieProvider) alias(incident *m.Incident) string {
+	if incident.Fingerprint != "" {
+		return incident.Fingerprint
+	}
+	return incident.ID
+}
+
+func (p *OpsgenieProvider) priority(severity string) string {
+	if priority, ok := p.priorityMapping[strings.ToLower(severity)]; ok {
+		return priority
+	}
+	return "P3" // Opsgenie default priority
+}
+
+// send posts a JSON body to the Opsgenie Alerts API
+func (p *OpsgenieProvider) send(ctx context.Context, apiKey, path string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Opsgenie request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Opsgenie request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Opsgenie request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Opsgenie processes alert requests asynchronously and answers 202 Accepted
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Opsgenie API returned non-success status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
 )
 
 // PagerDuty API v2 payload structures
@@ -41,7 +42,9 @@ func NewPagerDutyProvider(routingKey string) *PagerDutyProvider {
 }
 
 // TriggerOnCall creates an incident in PagerDuty using Events API v2
-func (p *PagerDutyProvider) TriggerOnCall(ctx context.Context, incidentID string, cfg *config.OnCallConfig) error {
+func (p *PagerDutyProvider) TriggerOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	incidentID := incident.ID
+
 	// Use the override config if provided, otherwise use the default
 	routingKey := p.routingKey
 	if cfg != nil && cfg.PagerDuty.RoutingKey != "" {
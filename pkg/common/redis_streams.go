@@ -0,0 +1,365 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisStreamsBlock      = 5 * time.Second
+	redisStreamsRetryDelay = 5 * time.Second
+)
+
+// RedisStreamsListener consumes the incidents added to Redis streams with a consumer group.
+// An entry is acknowledged once its incident is created. Entries left pending by a failure
+// or a crashed replica are claimed again with XAUTOCLAIM, so each entry is handled at least once.
+type RedisStreamsListener struct {
+	client        *redis.Client
+	streams       []string
+	group         string
+	consumer      string
+	field         string
+	batchSize     int64
+	claimIdle     time.Duration
+	maxDeliveries int64
+
+	handled atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+func NewRedisStreamsListener(cfg config.RedisStreamsConfig, client *redis.Client) (*RedisStreamsListener, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis streams require the redis connection")
+	}
+
+	l := &RedisStreamsListener{
+		client:        client,
+		streams:       cfg.Streams,
+		group:         cfg.Group,
+		consumer:      cfg.Consumer,
+		field:         cfg.Field,
+		batchSize:     int64(cfg.BatchSize),
+		maxDeliveries: int64(cfg.MaxDeliveries),
+		claimIdle:     5 * time.Minute,
+	}
+
+	if len(l.streams) == 0 {
+		l.streams = []string{"versus:incidents"}
+	}
+	if l.group == "" {
+		l.group = "versus-incident"
+	}
+	if l.consumer == "" {
+		if l.consumer, _ = os.Hostname(); l.consumer == "" {
+			l.consumer = "versus-incident"
+		}
+	}
+	if l.field == "" {
+		l.field = "payload"
+	}
+	if l.batchSize <= 0 {
+		l.batchSize = 10
+	}
+	if l.maxDeliveries <= 0 {
+		l.maxDeliveries = 5
+	}
+
+	if cfg.ClaimIdle != "" {
+		idle, err := time.ParseDuration(cfg.ClaimIdle)
+		if err != nil || idle <= 0 {
+			return nil, fmt.Errorf("invalid redis streams claim_idle '%s'", cfg.ClaimIdle)
+		}
+		l.claimIdle = idle
+	}
+
+	return l, nil
+}
+
+// StartListening creates the consumer group when needed, handles the entries this consumer
+// left pending before a restart, then reads new entries and claims the stale ones
+func (l *RedisStreamsListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	ctx := context.Background()
+
+	for _, stream := range l.streams {
+		err := l.client.XGroupCreateMkStream(ctx, stream, l.group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("failed to create the redis streams group %s of %s: %w", l.group, stream, err)
+		}
+	}
+
+	log.Printf("Redis streams listener started for %s with group %s as %s", strings.Join(l.streams, ", "), l.group, l.consumer)
+
+	for _, stream := range l.streams {
+		if err := l.readPending(ctx, stream, handler); err != nil {
+			return fmt.Errorf("redis streams listener: %w", err)
+		}
+	}
+
+	// XREADGROUP takes the streams, then an ID per stream, ">" for the entries never delivered
+	args := append([]string{}, l.streams...)
+	for range l.streams {
+		args = append(args, ">")
+	}
+
+	// Wake up for the claims even when no entry is added
+	block := min(redisStreamsBlock, l.claimIdle/2)
+
+	nextClaim := time.Now().Add(l.claimIdle / 2)
+	for {
+		if time.Now().After(nextClaim) {
+			for _, stream := range l.streams {
+				l.claim(ctx, stream, handler)
+			}
+			nextClaim = time.Now().Add(l.claimIdle / 2)
+		}
+
+		streams, err := l.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    l.group,
+			Consumer: l.consumer,
+			Streams:  args,
+			Count:    l.batchSize,
+			Block:    block,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			// Keep consuming once Redis is back, the pending entries are claimed then
+			log.Printf("Failed to read redis streams, retrying in %s: %v", redisStreamsRetryDelay, err)
+			time.Sleep(redisStreamsRetryDelay)
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				l.handle(ctx, s.Stream, msg, handler)
+			}
+		}
+	}
+}
+
+// readPending handles the entries delivered to this consumer that weren't acknowledged
+func (l *RedisStreamsListener) readPending(ctx context.Context, stream string, handler func(content *map[string]interface{}) error) error {
+	start := "0"
+	for {
+		streams, err := l.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    l.group,
+			Consumer: l.consumer,
+			Streams:  []string{stream, start},
+			Count:    l.batchSize,
+		}).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(streams) == 0 || len(streams[0].Messages) == 0 {
+			return nil
+		}
+
+		for _, msg := range streams[0].Messages {
+			l.handle(ctx, stream, msg, handler)
+			start = msg.ID
+		}
+	}
+}
+
+// claim takes over the entries pending for longer than claimIdle, and drops the ones
+// delivered max_deliveries times, an entry that always fails would be retried forever otherwise
+func (l *RedisStreamsListener) claim(ctx context.Context, stream string, handler func(content *map[string]interface{}) error) {
+	start := "0-0"
+	for {
+		messages, next, err := l.autoClaim(ctx, stream, start)
+		if err != nil {
+			log.Printf("Failed to claim the pending entries of redis stream %s: %v", stream, err)
+			return
+		}
+
+		for _, msg := range messages {
+			if msg.Values == nil {
+				// Entries deleted from the stream while pending, Redis 7 drops them by itself
+				l.ack(ctx, stream, msg.ID)
+				continue
+			}
+
+			if deliveries := l.deliveries(ctx, stream, msg.ID); deliveries > l.maxDeliveries {
+				l.dropped.Add(1)
+				log.Printf("Dropped redis stream entry %s/%s after %d deliveries", stream, msg.ID, deliveries-1)
+				l.ack(ctx, stream, msg.ID)
+				continue
+			}
+
+			l.handle(ctx, stream, msg, handler)
+		}
+
+		if next == "0-0" || next == "" {
+			return
+		}
+		start = next
+	}
+}
+
+// autoClaim runs XAUTOCLAIM as a plain command, the XAutoClaim of go-redis v8 rejects the
+// three elements reply of Redis 7. Deleted entries have nil values.
+func (l *RedisStreamsListener) autoClaim(ctx context.Context, stream, start string) ([]redis.XMessage, string, error) {
+	reply, err := l.client.Do(ctx, "XAUTOCLAIM", stream, l.group, l.consumer,
+		l.claimIdle.Milliseconds(), start, "COUNT", l.batchSize).Slice()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(reply) < 2 {
+		return nil, "", fmt.Errorf("unexpected XAUTOCLAIM reply")
+	}
+
+	next, _ := reply[0].(string)
+	entries, _ := reply[1].([]interface{})
+
+	var messages []redis.XMessage
+	for _, e := range entries {
+		entry, ok := e.([]interface{})
+		if !ok || len(entry) != 2 {
+			continue
+		}
+
+		msg := redis.XMessage{}
+		msg.ID, _ = entry[0].(string)
+
+		if fields, ok := entry[1].([]interface{}); ok {
+			msg.Values = make(map[string]interface{}, len(fields)/2)
+			for i := 0; i+1 < len(fields); i += 2 {
+				if key, ok := fields[i].(string); ok {
+					msg.Values[key] = fields[i+1]
+				}
+			}
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, next, nil
+}
+
+// deliveries returns how many times the entry was delivered, the claim included
+func (l *RedisStreamsListener) deliveries(ctx context.Context, stream, id string) int64 {
+	pending, err := l.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  l.group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return 0
+	}
+	return pending[0].RetryCount
+}
+
+// handle acknowledges the entry once its incident is created. A failed entry stays pending
+// and is claimed again after claimIdle. Entries that can't be decoded are dropped.
+func (l *RedisStreamsListener) handle(ctx context.Context, stream string, msg redis.XMessage, handler func(content *map[string]interface{}) error) {
+	content, err := l.content(msg.Values)
+	if err != nil {
+		l.dropped.Add(1)
+		log.Printf("Dropped redis stream entry %s/%s: %v", stream, msg.ID, err)
+		l.ack(ctx, stream, msg.ID)
+		return
+	}
+
+	if err := handler(&content); err != nil {
+		l.failed.Add(1)
+		log.Printf("Failed to handle redis stream entry %s/%s, retrying after %s: %v", stream, msg.ID, l.claimIdle, err)
+		return
+	}
+
+	l.handled.Add(1)
+	l.ack(ctx, stream, msg.ID)
+}
+
+func (l *RedisStreamsListener) ack(ctx context.Context, stream, id string) {
+	if err := l.client.XAck(ctx, stream, l.group, id).Err(); err != nil {
+		log.Printf("Failed to acknowledge redis stream entry %s/%s: %v", stream, id, err)
+	}
+}
+
+// content decodes the incident in the JSON field of the entry, e.g. XADD versus:incidents * payload '{...}'.
+// Entries without it are flat incidents, e.g. XADD versus:incidents * ServiceName billing Logs timeout
+func (l *RedisStreamsListener) content(values map[string]interface{}) (map[string]interface{}, error) {
+	raw, ok := values[l.field]
+	if !ok {
+		content := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			content[k] = v
+		}
+		return content, nil
+	}
+
+	s, _ := raw.(string)
+
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &content); err != nil {
+		return nil, fmt.Errorf("invalid JSON in field %s: %w", l.field, err)
+	}
+	if content == nil {
+		return nil, fmt.Errorf("field %s is not a JSON object", l.field)
+	}
+	return content, nil
+}
+
+// QueueStatus reports the entries pending per stream, and the entries not read yet on Redis 7
+func (l *RedisStreamsListener) QueueStatus() map[string]interface{} {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	streams := make([]map[string]interface{}, 0, len(l.streams))
+	for _, stream := range l.streams {
+		status := map[string]interface{}{"stream": stream}
+
+		// XINFO GROUPS as a plain command, go-redis v8 rejects the additional fields of Redis 7
+		groups, err := l.client.Do(ctx, "XINFO", "GROUPS", stream).Slice()
+		if err != nil {
+			status["error"] = err.Error()
+		}
+
+		for _, g := range groups {
+			fields, _ := g.([]interface{})
+			info := make(map[string]interface{}, len(fields)/2)
+			for i := 0; i+1 < len(fields); i += 2 {
+				if key, ok := fields[i].(string); ok {
+					info[key] = fields[i+1]
+				}
+			}
+
+			if info["name"] != l.group {
+				continue
+			}
+			status["pending"] = info["pending"]
+			status["last_delivered_id"] = info["last-delivered-id"]
+			if lag, ok := info["lag"]; ok && lag != nil {
+				status["lag"] = lag
+			}
+		}
+
+		streams = append(streams, status)
+	}
+
+	return map[string]interface{}{
+		"type":     "redis_streams",
+		"group":    l.group,
+		"consumer": l.consumer,
+		"streams":  streams,
+		"handled":  l.handled.Load(),
+		"failed":   l.failed.Load(),
+		"dropped":  l.dropped.Load(),
+	}
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"log"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -50,7 +51,7 @@ func (s *SlackProvider) sendResolvedAlert(i *m.Incident) error {
 	color := "#36A64F"
 
 	// Send standard message
-	return s.sendStandardMessage(messageText, color)
+	return s.sendStandardMessage(i, messageText, color)
 }
 
 // sendUnresolvedAlert handles messaging for unresolved incidents
@@ -70,10 +71,10 @@ func (s *SlackProvider) sendUnresolvedAlert(i *m.Incident) error {
 	// Determine whether to use button or standard message format
 	if !s.msgProps.DisableButton && ackURL != "" {
 		// Send message with interactive button
-		return s.sendMessageWithButton(messageText, color, ackURL, i.ID)
+		return s.sendMessageWithButton(i, messageText, color, ackURL)
 	} else {
 		// Send standard message
-		return s.sendStandardMessage(messageText, color)
+		return s.sendStandardMessage(i, messageText, color)
 	}
 }
 
@@ -134,7 +135,7 @@ func (s *SlackProvider) renderTemplateWithContent(content map[string]interface{}
 }
 
 // sendMessageWithButton sends a message with an interactive button for acknowledgment
-func (s *SlackProvider) sendMessageWithButton(messageText, color, ackURL, incidentID string) error {
+func (s *SlackProvider) sendMessageWithButton(i *m.Incident, messageText, color, ackURL string) error {
 	// Create text block for the main message content
 	headerText := slack.NewTextBlockObject("mrkdwn", messageText, false, false)
 	headerSection := slack.NewSectionBlock(headerText, nil, nil)
@@ -147,7 +148,7 @@ func (s *SlackProvider) sendMessageWithButton(messageText, color, ackURL, incide
 
 	// Create button for acknowledgment
 	btnText := slack.NewTextBlockObject("plain_text", buttonText, false, false)
-	btnElement := slack.NewButtonBlockElement("ack_incident", incidentID, btnText)
+	btnElement := slack.NewButtonBlockElement("ack_incident", i.ID, btnText)
 	btnElement.URL = ackURL // Use URL for direct navigation on click
 
 	// Set button style if specified in config
@@ -161,36 +162,90 @@ func (s *SlackProvider) sendMessageWithButton(messageText, color, ackURL, incide
 	actionBlock := slack.NewActionBlock("incident_actions", btnElement)
 
 	// Build the message with blocks
-	_, _, err := s.client.PostMessage(
-		s.channelID,
-		slack.MsgOptionAttachments(slack.Attachment{
+	ts, err := s.postWithImage(i, func(images []slack.Block) []slack.MsgOption {
+		return append(s.threadOptions(i), slack.MsgOptionAttachments(slack.Attachment{
 			Color: color,
 			Blocks: slack.Blocks{
-				BlockSet: []slack.Block{headerSection, actionBlock},
+				BlockSet: append([]slack.Block{headerSection}, append(images, actionBlock)...),
 			},
-		}),
-	)
-
+		}))
+	})
 	if err != nil {
 		return fmt.Errorf("failed to post message with button: %w", err)
 	}
 
+	recordThread(i, "slack:"+s.channelID, ts)
+
 	return nil
 }
 
 // sendStandardMessage sends a message using standard Slack attachments
-func (s *SlackProvider) sendStandardMessage(messageText, color string) error {
-	_, _, err := s.client.PostMessage(
-		s.channelID,
-		slack.MsgOptionAttachments(slack.Attachment{
+func (s *SlackProvider) sendStandardMessage(i *m.Incident, messageText, color string) error {
+	ts, err := s.postWithImage(i, func(images []slack.Block) []slack.MsgOption {
+		attachment := slack.Attachment{
 			Text:  messageText,
 			Color: color,
-		}),
-	)
+		}
 
+		// The image needs blocks, and the text of an attachment with blocks is ignored
+		if len(images) > 0 {
+			text := slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", messageText, false, false), nil, nil)
+			attachment.Text = ""
+			attachment.Blocks = slack.Blocks{BlockSet: append([]slack.Block{text}, images...)}
+		}
+
+		return append(s.threadOptions(i), slack.MsgOptionAttachments(attachment))
+	})
 	if err != nil {
 		return fmt.Errorf("failed to post standard message: %w", err)
 	}
 
+	recordThread(i, "slack:"+s.channelID, ts)
+
+	return nil
+}
+
+// postWithImage posts the message built with the image of the alert. Slack rejects the whole message
+// when it can't download the image, e.g. from a Grafana that isn't public, so the alert is posted
+// again without it: the image is best effort, like on Telegram.
+func (s *SlackProvider) postWithImage(i *m.Incident, build func(images []slack.Block) []slack.MsgOption) (string, error) {
+	images := imageBlocks(i)
+
+	_, ts, err := s.client.PostMessage(s.channelID, build(images)...)
+	if err != nil && len(images) > 0 {
+		log.Printf("Failed to post incident %s to Slack with its image, posting it without: %v", i.ID, err)
+		_, ts, err = s.client.PostMessage(s.channelID, build(nil)...)
+	}
+
+	return ts, err
+}
+
+// imageBlocks shows the image of the alert, e.g. the panel of a Grafana alert
+func imageBlocks(i *m.Incident) []slack.Block {
+	image := alertImage(i)
+	if image == "" {
+		return nil
+	}
+
+	alt := i.Title // Required by Slack
+	if alt == "" {
+		alt = "Alert image"
+	}
+
+	return []slack.Block{slack.NewImageBlock(image, alt, "", nil)}
+}
+
+// threadOptions replies in the thread of the group notification, when the incident belongs to a notified group
+func (s *SlackProvider) threadOptions(i *m.Incident) []slack.MsgOption {
+	if ts := i.Threads["slack:"+s.channelID]; ts != "" {
+		return []slack.MsgOption{slack.MsgOptionTS(ts)}
+	}
 	return nil
 }
+
+// recordThread keeps the first message posted for a group, so the updates of the group reply to it
+func recordThread(i *m.Incident, key, ref string) {
+	if i.Threads != nil && i.Threads[key] == "" && ref != "" {
+		i.Threads[key] = ref
+	}
+}
@@ -0,0 +1,442 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+const (
+	defaultSMTPMaxMessageSize = 10 << 20
+	maxSMTPRecipients         = 100
+	smtpIdleTimeout           = 5 * time.Minute
+)
+
+// SMTPListener is an embedded SMTP server that turns the received emails into incidents,
+// for tools that only send alert emails
+type SMTPListener struct {
+	address        string
+	domain         string
+	maxMessageSize int
+	allowedSenders []string
+	recipients     []config.SMTPRecipientConfig
+	extractors     []emailExtractor
+}
+
+// emailExtractor sets labels from the subject, the body or a header of an email
+type emailExtractor struct {
+	field   string
+	from    string
+	pattern *regexp.Regexp
+}
+
+func NewSMTPListener(cfg config.SMTPConfig) (*SMTPListener, error) {
+	l := &SMTPListener{
+		address:        cfg.Address,
+		domain:         cfg.Domain,
+		maxMessageSize: cfg.MaxMessageSize,
+	}
+
+	if l.address == "" {
+		l.address = ":2525"
+	}
+	if l.domain == "" {
+		if l.domain, _ = os.Hostname(); l.domain == "" {
+			l.domain = "localhost"
+		}
+	}
+	if l.maxMessageSize <= 0 {
+		l.maxMessageSize = defaultSMTPMaxMessageSize
+	}
+
+	for _, sender := range cfg.AllowedSenders {
+		l.allowedSenders = append(l.allowedSenders, strings.ToLower(strings.TrimSpace(sender)))
+	}
+
+	for _, rc := range cfg.Recipients {
+		if rc.Address == "" || rc.Team == "" {
+			return nil, fmt.Errorf("smtp recipient needs an address and a team")
+		}
+		l.recipients = append(l.recipients, config.SMTPRecipientConfig{
+			Address: strings.ToLower(strings.TrimSpace(rc.Address)),
+			Team:    rc.Team,
+		})
+	}
+
+	for _, ec := range cfg.Extract {
+		pattern, err := regexp.Compile(ec.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp extract pattern '%s': %w", ec.Pattern, err)
+		}
+
+		if ec.Field == "" && !slices.ContainsFunc(pattern.SubexpNames(), func(name string) bool { return name != "" }) {
+			return nil, fmt.Errorf("smtp extract pattern '%s' needs a field or named groups", ec.Pattern)
+		}
+
+		from := strings.ToLower(ec.From)
+		if from != "" && from != "subject" && from != "body" {
+			from = textproto.CanonicalMIMEHeaderKey(ec.From)
+		}
+
+		l.extractors = append(l.extractors, emailExtractor{field: ec.Field, from: from, pattern: pattern})
+	}
+
+	return l, nil
+}
+
+// StartListening accepts SMTP connections until the listener fails, without the teams of the recipients
+func (l *SMTPListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	return l.StartTeamListening(func(_ string, content *map[string]interface{}) error {
+		return handler(content)
+	})
+}
+
+// StartTeamListening accepts SMTP connections until the listener fails, each incident is created for the team of its recipients
+func (l *SMTPListener) StartTeamListening(handler func(teamID string, content *map[string]interface{}) error) error {
+	ln, err := net.Listen("tcp", l.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for smtp on %s: %w", l.address, err)
+	}
+	defer ln.Close()
+
+	log.Printf("SMTP listener started on %s", l.address)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("smtp listener: %w", err)
+		}
+
+		go l.serve(conn, handler)
+	}
+}
+
+// serve runs an SMTP session (RFC 5321), without authentication or STARTTLS
+func (l *SMTPListener) serve(conn net.Conn, handler func(teamID string, content *map[string]interface{}) error) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+
+	reply := func(code int, message string) {
+		tp.PrintfLine("%d %s", code, message)
+	}
+
+	var (
+		sender     string
+		hasSender  bool
+		recipients []string
+	)
+	reset := func() {
+		sender, hasSender, recipients = "", false, nil
+	}
+
+	reply(220, l.domain+" ESMTP Versus Incident")
+
+	for {
+		conn.SetDeadline(time.Now().Add(smtpIdleTimeout))
+
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reset()
+			reply(250, l.domain)
+
+		case "EHLO":
+			reset()
+			tp.PrintfLine("250-%s", l.domain)
+			tp.PrintfLine("250-SIZE %d", l.maxMessageSize)
+			tp.PrintfLine("250 8BITMIME")
+
+		case "MAIL":
+			address, params, ok := smtpPath(arg, "FROM:")
+			if !ok {
+				reply(501, "Syntax: MAIL FROM:<address>")
+				continue
+			}
+			if hasSender {
+				reply(503, "Sender already specified")
+				continue
+			}
+			if size, err := strconv.Atoi(smtpParam(params, "SIZE")); err == nil && size > l.maxMessageSize {
+				reply(552, "Message size exceeds the limit")
+				continue
+			}
+			sender, hasSender = address, true
+			reply(250, "OK")
+
+		case "RCPT":
+			if !hasSender {
+				reply(503, "Need MAIL before RCPT")
+				continue
+			}
+			address, _, ok := smtpPath(arg, "TO:")
+			if !ok || address == "" {
+				reply(501, "Syntax: RCPT TO:<address>")
+				continue
+			}
+			if len(recipients) >= maxSMTPRecipients {
+				reply(452, "Too many recipients")
+				continue
+			}
+			recipients = append(recipients, address)
+			reply(250, "OK")
+
+		case "DATA":
+			if len(recipients) == 0 {
+				reply(503, "Need RCPT before DATA")
+				continue
+			}
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+
+			dr := tp.DotReader()
+			data, err := io.ReadAll(io.LimitReader(dr, int64(l.maxMessageSize)+1))
+			if err != nil {
+				return
+			}
+			if len(data) > l.maxMessageSize {
+				if _, err := io.Copy(io.Discard, dr); err != nil {
+					return
+				}
+				reply(552, "Message size exceeds the limit")
+			} else {
+				reply(l.receive(sender, recipients, data, handler))
+			}
+			reset()
+
+		case "RSET":
+			reset()
+			reply(250, "OK")
+
+		case "NOOP":
+			reply(250, "OK")
+
+		case "VRFY":
+			reply(252, "Cannot VRFY user")
+
+		case "QUIT":
+			reply(221, "Bye")
+			return
+
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// smtpPath parses "FROM:<address> PARAMS" and returns the lower case address and the parameters
+func smtpPath(arg, prefix string) (string, string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", "", false
+	}
+
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		address, params, _ := strings.Cut(rest, " ")
+		return strings.ToLower(address), params, address != ""
+	}
+
+	address, params, ok := strings.Cut(rest[1:], ">")
+	if !ok {
+		return "", "", false
+	}
+
+	// Drop the source route of old clients, e.g. <@relay.example.com:user@example.com>
+	if i := strings.LastIndex(address, ":"); strings.HasPrefix(address, "@") && i >= 0 {
+		address = address[i+1:]
+	}
+
+	return strings.ToLower(address), strings.TrimSpace(params), true
+}
+
+func smtpParam(params, name string) string {
+	for _, param := range strings.Fields(params) {
+		if key, value, _ := strings.Cut(param, "="); strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// receive creates an incident per team of the recipients and returns the SMTP reply.
+// Failed notifications are only logged, a retry of the sender would notify twice.
+func (l *SMTPListener) receive(sender string, recipients []string, data []byte, handler func(teamID string, content *map[string]interface{}) error) (int, string) {
+	msg, err := parseEmailMessage(data)
+	if err != nil {
+		log.Printf("Rejected an email from %s: %v", sender, err)
+		return 554, "Invalid message"
+	}
+
+	if !l.allowed(sender, msg.From) {
+		log.Printf("Rejected an email from %s: sender not allowed", sender)
+		return 550, "Sender not allowed"
+	}
+
+	content := l.emailContent(sender, recipients, msg)
+
+	for _, team := range l.teams(recipients) {
+		teamContent := make(map[string]interface{}, len(content))
+		for k, v := range content {
+			teamContent[k] = v
+		}
+
+		if err := handler(team, &teamContent); err != nil {
+			log.Printf("Failed to create the incident of an email from %s: %v", sender, err)
+		}
+	}
+
+	return 250, "OK"
+}
+
+// allowed reports whether the envelope sender or the From header is an allowed sender,
+// bounce addresses of mailing services often differ from the From header
+func (l *SMTPListener) allowed(sender, from string) bool {
+	if len(l.allowedSenders) == 0 {
+		return true
+	}
+
+	for _, allowed := range l.allowedSenders {
+		if matchEmailAddress(allowed, sender) || matchEmailAddress(allowed, from) {
+			return true
+		}
+	}
+	return false
+}
+
+// teams returns the teams of the recipients in order, or the default team when none is mapped
+func (l *SMTPListener) teams(recipients []string) []string {
+	var teams []string
+	for _, recipient := range recipients {
+		for _, rc := range l.recipients {
+			if matchEmailAddress(rc.Address, recipient) {
+				if !slices.Contains(teams, rc.Team) {
+					teams = append(teams, rc.Team)
+				}
+				break
+			}
+		}
+	}
+
+	if len(teams) == 0 {
+		return []string{""}
+	}
+	return teams
+}
+
+// matchEmailAddress matches an address, or a domain when the pattern starts with "@"
+func matchEmailAddress(pattern, address string) bool {
+	if address == "" {
+		return false
+	}
+	if strings.HasPrefix(pattern, "@") {
+		return strings.HasSuffix(address, pattern)
+	}
+	return pattern == address
+}
+
+// emailContent is the payload of the incident, the sender and the extracted fields are labels for routing
+func (l *SMTPListener) emailContent(sender string, recipients []string, msg *emailMessage) map[string]interface{} {
+	from := msg.From
+	if from == "" {
+		from = sender
+	}
+
+	timestamp := msg.Date
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	labels := map[string]interface{}{
+		"from": from,
+	}
+	l.extract(msg, labels)
+
+	to := make([]interface{}, len(recipients))
+	for i, r := range recipients {
+		to[i] = r
+	}
+
+	headers := make(map[string]interface{}, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+
+	content := map[string]interface{}{
+		"source":    "email",
+		"from":      from,
+		"sender":    sender,
+		"to":        to,
+		"subject":   msg.Subject,
+		"body":      msg.Body,
+		"headers":   headers,
+		"timestamp": timestamp.Format(time.RFC3339Nano),
+		"labels":    labels,
+	}
+
+	if msg.MessageID != "" {
+		content["message_id"] = msg.MessageID
+	}
+
+	return content
+}
+
+// extract applies the extraction rules in order, a label set by a rule isn't overwritten by a later one
+func (l *SMTPListener) extract(msg *emailMessage, labels map[string]interface{}) {
+	set := func(name, value string) {
+		if value = strings.TrimSpace(value); value == "" {
+			return
+		}
+		if _, ok := labels[name]; !ok {
+			labels[name] = value
+		}
+	}
+
+	for _, e := range l.extractors {
+		var texts []string
+		switch e.from {
+		case "":
+			texts = []string{msg.Subject, msg.Body}
+		case "subject":
+			texts = []string{msg.Subject}
+		case "body":
+			texts = []string{msg.Body}
+		default:
+			texts = []string{msg.Headers[e.from]}
+		}
+
+		for _, text := range texts {
+			match := e.pattern.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+
+			for i, name := range e.pattern.SubexpNames() {
+				if name != "" {
+					set(name, match[i])
+				}
+			}
+
+			if e.field != "" {
+				value := match[0]
+				if len(match) > 1 {
+					value = match[1]
+				}
+				set(e.field, value)
+			}
+			break
+		}
+	}
+}
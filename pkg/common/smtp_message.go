@@ -0,0 +1,187 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Bodies are cut to this size, chat providers reject much smaller messages anyway
+const maxEmailBodySize = 64 * 1024
+
+// Multipart messages nested deeper than this are not read
+const maxEmailPartDepth = 5
+
+// emailMessage is a received email with the headers decoded and the body as plain text
+type emailMessage struct {
+	From      string // Address of the From header
+	Subject   string
+	Body      string
+	Headers   map[string]string
+	MessageID string
+	Date      time.Time
+}
+
+var (
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreakPattern  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h[1-6])>`)
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	blankLinesPattern = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+func parseEmailMessage(data []byte) (*emailMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the email: %w", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	decode := func(value string) string {
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	email := &emailMessage{
+		Subject:   decode(msg.Header.Get("Subject")),
+		Headers:   make(map[string]string, len(msg.Header)),
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<> "),
+	}
+
+	for name, values := range msg.Header {
+		decoded := make([]string, len(values))
+		for i, v := range values {
+			decoded[i] = decode(v)
+		}
+		email.Headers[name] = strings.Join(decoded, ", ")
+	}
+
+	if from := msg.Header.Get("From"); from != "" {
+		if addr, err := mail.ParseAddress(decode(from)); err == nil {
+			email.From = strings.ToLower(addr.Address)
+		}
+	}
+
+	if date, err := msg.Header.Date(); err == nil {
+		email.Date = date
+	}
+
+	body, _, err := emailText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the email body: %w", err)
+	}
+
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if len(body) > maxEmailBodySize {
+		body = strings.ToValidUTF8(body[:maxEmailBodySize], "") + "\n[truncated]"
+	}
+	email.Body = body
+
+	return email, nil
+}
+
+// emailText returns the text of a part and whether it was HTML.
+// The plain text alternative of a multipart message is preferred, attachments are skipped.
+func emailText(contentType, encoding string, body io.Reader, depth int) (string, bool, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if depth >= maxEmailPartDepth {
+			return "", false, nil
+		}
+
+		var htmlText string
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", false, err
+			}
+
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+
+			// The multipart reader already decodes quoted-printable parts and drops their encoding header
+			text, isHTML, err := emailText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, depth+1)
+			if err != nil {
+				return "", false, err
+			}
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			if !isHTML {
+				return text, false, nil
+			}
+			if htmlText == "" {
+				htmlText = text
+			}
+		}
+		return htmlText, htmlText != "", nil
+
+	case mediaType == "text/plain", mediaType == "text/html":
+		data, err := io.ReadAll(io.LimitReader(body, 4*maxEmailBodySize))
+		if err != nil {
+			return "", false, err
+		}
+
+		text := decodeCharset(data, params["charset"])
+		if mediaType == "text/html" {
+			return htmlToText(text), true, nil
+		}
+		return text, false, nil
+	}
+
+	return "", false, nil
+}
+
+// decodeCharset converts Latin-1 bodies to UTF-8, other charsets are kept as they are
+func decodeCharset(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return string(data)
+}
+
+// htmlToText keeps the text of an HTML body with its line breaks
+func htmlToText(s string) string {
+	s = htmlHiddenPattern.ReplaceAllString(s, "")
+	s = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTagPattern.ReplaceAllString(s, ""))
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+func TestSMTPPath(t *testing.T) {
+	tests := []struct {
+		arg        string
+		prefix     string
+		wantAddr   string
+		wantParams string
+		wantOK     bool
+	}{
+		{"FROM:<Alerts@Example.com>", "FROM:", "alerts@example.com", "", true},
+		{"from: <alerts@example.com> SIZE=1024 BODY=8BITMIME", "FROM:", "alerts@example.com", "SIZE=1024 BODY=8BITMIME", true},
+		{"FROM:<>", "FROM:", "", "", true}, // Null sender of bounces
+		{"TO:<@relay.example.com:oncall@example.com>", "TO:", "oncall@example.com", "", true},
+		{"TO:oncall@example.com NOTIFY=NEVER", "TO:", "oncall@example.com", "NOTIFY=NEVER", true},
+		{"TO:<oncall@example.com", "TO:", "", "", false},
+		{"TO:", "TO:", "", "", false},
+		{"FROM:<alerts@example.com>", "TO:", "", "", false},
+	}
+
+	for _, tt := range tests {
+		addr, params, ok := smtpPath(tt.arg, tt.prefix)
+		if addr != tt.wantAddr || params != tt.wantParams || ok != tt.wantOK {
+			t.Errorf("smtpPath(%q, %q) = %q, %q, %v, want %q, %q, %v", tt.arg, tt.prefix, addr, params, ok, tt.wantAddr, tt.wantParams, tt.wantOK)
+		}
+	}
+
+	if size := smtpParam("BODY=8BITMIME size=1024", "SIZE"); size != "1024" {
+		t.Errorf("smtpParam() = %q, want %q", size, "1024")
+	}
+}
+
+func TestSMTPExtract(t *testing.T) {
+	l, err := NewSMTPListener(config.SMTPConfig{
+		Extract: []config.SMTPExtractConfig{
+			{Pattern: `\[(?P<severity>[A-Z]+)\]`},
+			{Field: "host", Pattern: `host: (\S+)`, From: "body"},
+			{Field: "priority", Pattern: `\d`, From: "x-priority"},
+			{Field: "severity", Pattern: `severity=(\w+)`}, // Set by the first rule already
+			{Field: "job", Pattern: `Job (\w+)`, From: "body"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &emailMessage{
+		Subject: "[CRITICAL] Backup failed",
+		Body:    "severity=low\nhost: db-01",
+		Headers: map[string]string{"X-Priority": "1 (Highest)"},
+	}
+
+	labels := map[string]interface{}{}
+	l.extract(msg, labels)
+
+	want := map[string]interface{}{"severity": "CRITICAL", "host": "db-01", "priority": "1"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("extract() = %v, want %v", labels, want)
+	}
+}
+
+func TestNewSMTPListenerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.SMTPConfig
+	}{
+		{"recipient without team", config.SMTPConfig{Recipients: []config.SMTPRecipientConfig{{Address: "oncall@example.com"}}}},
+		{"invalid pattern", config.SMTPConfig{Extract: []config.SMTPExtractConfig{{Field: "host", Pattern: "("}}}},
+		{"pattern without field", config.SMTPConfig{Extract: []config.SMTPExtractConfig{{Pattern: `host: \S+`}}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewSMTPListener(tt.cfg); err == nil {
+			t.Errorf("NewSMTPListener() with %s succeeded", tt.name)
+		}
+	}
+}
+
+func TestSMTPReceive(t *testing.T) {
+	l, err := NewSMTPListener(config.SMTPConfig{
+		AllowedSenders: []string{"@vendor.com"},
+		Recipients: []config.SMTPRecipientConfig{
+			{Address: "@payments.example.com", Team: "payments"},
+			{Address: "search@example.com", Team: "search"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := strings.Join([]string{
+		"From: Backup <backup@vendor.com>",
+		"Subject: =?UTF-8?Q?Backup_failed?=",
+		"Message-ID: <abc@vendor.com>",
+		`Content-Type: multipart/alternative; boundary="b"`,
+		"",
+		"--b",
+		"Content-Type: text/html",
+		"",
+		"<p>HTML body</p>",
+		"--b",
+		"Content-Type: text/plain",
+		"",
+		"Plain body",
+		"--b--",
+		"",
+	}, "\r\n")
+
+	tests := []struct {
+		name       string
+		sender     string
+		recipients []string
+		wantCode   int
+		wantTeams  []string
+	}{
+		{"teams of the recipients", "bounce@mail.vendor.com", []string{"a@payments.example.com", "search@example.com", "b@payments.example.com"}, 250, []string{"payments", "search"}},
+		{"default team", "bounce@mail.vendor.com", []string{"oncall@example.com"}, 250, []string{""}},
+		{"sender allowed by the From header", "bounce@mailer.net", []string{"oncall@example.com"}, 250, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var teams []string
+			code, _ := l.receive(tt.sender, tt.recipients, []byte(email), func(teamID string, content *map[string]interface{}) error {
+				teams = append(teams, teamID)
+				if c := *content; c["subject"] != "Backup failed" || c["body"] != "Plain body" || c["message_id"] != "abc@vendor.com" || c["from"] != "backup@vendor.com" {
+					t.Errorf("unexpected content %v", c)
+				}
+				return nil
+			})
+
+			if code != tt.wantCode || !reflect.DeepEqual(teams, tt.wantTeams) {
+				t.Errorf("receive() = %d with teams %q, want %d with teams %q", code, teams, tt.wantCode, tt.wantTeams)
+			}
+		})
+	}
+
+	l.allowedSenders = []string{"alerts@example.com"}
+	if code, _ := l.receive("backup@vendor.com", []string{"oncall@example.com"}, []byte(email), func(string, *map[string]interface{}) error {
+		t.Error("handler called for a sender that isn't allowed")
+		return nil
+	}); code != 550 {
+		t.Errorf("receive() from a sender that isn't allowed = %d, want 550", code)
+	}
+}
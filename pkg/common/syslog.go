@@ -0,0 +1,308 @@
+package common
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+// Messages larger than this are dropped, UDP datagrams can't be larger anyway
+const maxSyslogMessageSize = 64 * 1024
+
+// SyslogListener receives syslog messages over UDP and TCP, optionally with TLS, and turns the
+// messages at or above the severity threshold into incidents
+type SyslogListener struct {
+	address    string
+	protocols  []string
+	tlsConfig  *tls.Config
+	severity   int
+	facilities []int
+	appNames   []string
+}
+
+func NewSyslogListener(cfg config.SyslogConfig) (*SyslogListener, error) {
+	l := &SyslogListener{
+		address:   cfg.Address,
+		protocols: cfg.Protocols,
+		appNames:  cfg.AppNames,
+	}
+
+	if l.address == "" {
+		l.address = ":514"
+	}
+	if len(l.protocols) == 0 {
+		l.protocols = []string{"udp"}
+	}
+	for _, p := range l.protocols {
+		if p != "udp" && p != "tcp" {
+			return nil, fmt.Errorf("invalid syslog protocol '%s', expected udp or tcp", p)
+		}
+	}
+
+	threshold := cfg.Severity
+	if threshold == "" {
+		threshold = "warning"
+	}
+	severity, err := parseSyslogSeverity(threshold)
+	if err != nil {
+		return nil, err
+	}
+	l.severity = severity
+
+	for _, name := range cfg.Facilities {
+		facility, err := parseSyslogFacility(name)
+		if err != nil {
+			return nil, err
+		}
+		l.facilities = append(l.facilities, facility)
+	}
+
+	if cfg.TLS.Enable {
+		if !slices.Contains(l.protocols, "tcp") {
+			return nil, fmt.Errorf("syslog TLS requires the tcp protocol")
+		}
+		if l.tlsConfig, err = syslogTLSConfig(cfg.TLS); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func syslogTLSConfig(cfg config.SyslogTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load syslog TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caCert, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read syslog client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to append syslog client CA")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// StartListening serves the configured protocols until one of them fails
+func (l *SyslogListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	errs := make(chan error, len(l.protocols))
+
+	for _, protocol := range l.protocols {
+		switch protocol {
+		case "udp":
+			conn, err := net.ListenPacket("udp", l.address)
+			if err != nil {
+				return fmt.Errorf("failed to listen for syslog on udp %s: %w", l.address, err)
+			}
+			go func() { errs <- l.serveUDP(conn, handler) }()
+
+		case "tcp":
+			ln, err := net.Listen("tcp", l.address)
+			if err != nil {
+				return fmt.Errorf("failed to listen for syslog on tcp %s: %w", l.address, err)
+			}
+			if l.tlsConfig != nil {
+				ln = tls.NewListener(ln, l.tlsConfig)
+			}
+			go func() { errs <- l.serveTCP(ln, handler) }()
+		}
+
+		log.Printf("Syslog listener started on %s %s", protocol, l.address)
+	}
+
+	return <-errs
+}
+
+func (l *SyslogListener) serveUDP(conn net.PacketConn, handler func(content *map[string]interface{}) error) error {
+	defer conn.Close()
+
+	buf := make([]byte, maxSyslogMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("syslog udp listener: %w", err)
+		}
+
+		l.handle(string(buf[:n]), handler)
+	}
+}
+
+func (l *SyslogListener) serveTCP(ln net.Listener, handler func(content *map[string]interface{}) error) error {
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("syslog tcp listener: %w", err)
+		}
+
+		go func() {
+			defer conn.Close()
+
+			r := bufio.NewReader(conn)
+			for {
+				frame, err := readSyslogFrame(r)
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						log.Printf("Syslog connection from %s closed: %v", conn.RemoteAddr(), err)
+					}
+					return
+				}
+
+				l.handle(frame, handler)
+			}
+		}()
+	}
+}
+
+// readSyslogFrame reads a message framed by octet counting, e.g. "42 <34>1 ...", or by a newline (RFC 6587)
+func readSyslogFrame(r *bufio.Reader) (string, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if first[0] >= '0' && first[0] <= '9' {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return "", err
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil || n <= 0 || n > maxSyslogMessageSize {
+			return "", fmt.Errorf("invalid frame length '%s'", strings.TrimSpace(length))
+		}
+
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return "", err
+		}
+		return string(frame), nil
+	}
+
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+
+		line = append(line, chunk...)
+		if len(line) > maxSyslogMessageSize {
+			return "", fmt.Errorf("message larger than %d bytes", maxSyslogMessageSize)
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+func (l *SyslogListener) handle(data string, handler func(content *map[string]interface{}) error) {
+	if strings.TrimSpace(data) == "" {
+		return
+	}
+
+	msg, err := parseSyslogMessage(data)
+	if err != nil {
+		log.Printf("Dropped invalid syslog message: %v", err)
+		return
+	}
+
+	if !l.matches(msg) {
+		return
+	}
+
+	content := syslogContent(msg)
+	if err := handler(&content); err != nil {
+		log.Printf("Failed to create the incident of a syslog message from %s: %v", msg.Hostname, err)
+	}
+}
+
+// matches applies the severity threshold and the filters, lower severities are more severe
+func (l *SyslogListener) matches(msg *syslogMessage) bool {
+	if msg.Severity > l.severity {
+		return false
+	}
+	if len(l.facilities) > 0 && !slices.Contains(l.facilities, msg.Facility) {
+		return false
+	}
+	if len(l.appNames) > 0 && !slices.Contains(l.appNames, msg.AppName) {
+		return false
+	}
+	return true
+}
+
+// syslogContent is the payload of the incident, host, app and severity are labels for routing
+func syslogContent(msg *syslogMessage) map[string]interface{} {
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	labels := map[string]interface{}{
+		"severity": syslogSeverityName(msg.Severity),
+	}
+	if msg.Hostname != "" {
+		labels["host"] = msg.Hostname
+	}
+	if msg.AppName != "" {
+		labels["app"] = msg.AppName
+	}
+
+	content := map[string]interface{}{
+		"source":    "syslog",
+		"facility":  syslogFacilityName(msg.Facility),
+		"severity":  syslogSeverityName(msg.Severity),
+		"host":      msg.Hostname,
+		"app":       msg.AppName,
+		"message":   msg.Message,
+		"timestamp": timestamp.Format(time.RFC3339Nano),
+		"labels":    labels,
+	}
+
+	if msg.ProcID != "" {
+		content["procid"] = msg.ProcID
+	}
+	if msg.MsgID != "" {
+		content["msgid"] = msg.MsgID
+	}
+	if len(msg.StructuredData) > 0 {
+		sd := make(map[string]interface{}, len(msg.StructuredData))
+		for id, params := range msg.StructuredData {
+			values := make(map[string]interface{}, len(params))
+			for k, v := range params {
+				values[k] = v
+			}
+			sd[id] = values
+		}
+		content["structured_data"] = sd
+	}
+
+	return content
+}
@@ -0,0 +1,225 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity levels of syslog, from the most severe
+var syslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+	"ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogMessage is a parsed RFC 5424 or RFC 3164 message
+type syslogMessage struct {
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string // SD-ID -> params, RFC 5424 only
+	Message        string
+}
+
+// parseSyslogSeverity accepts the names of the levels, with the usual aliases, or their number
+func parseSyslogSeverity(s string) (int, error) {
+	switch s = strings.ToLower(s); s {
+	case "emergency", "panic":
+		s = "emerg"
+	case "critical":
+		s = "crit"
+	case "error":
+		s = "err"
+	case "warn":
+		s = "warning"
+	case "informational":
+		s = "info"
+	}
+
+	for i, name := range syslogSeverities {
+		if s == name || s == strconv.Itoa(i) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown syslog severity '%s'", s)
+}
+
+// parseSyslogFacility accepts the names of the facilities or their number
+func parseSyslogFacility(s string) (int, error) {
+	s = strings.ToLower(s)
+	for i, name := range syslogFacilities {
+		if s == name || s == strconv.Itoa(i) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown syslog facility '%s'", s)
+}
+
+func syslogFacilityName(facility int) string {
+	if facility >= 0 && facility < len(syslogFacilities) {
+		return syslogFacilities[facility]
+	}
+	return strconv.Itoa(facility)
+}
+
+func syslogSeverityName(severity int) string {
+	if severity >= 0 && severity < len(syslogSeverities) {
+		return syslogSeverities[severity]
+	}
+	return strconv.Itoa(severity)
+}
+
+// parseSyslogMessage parses a message in the RFC 5424 format, or the BSD format of RFC 3164
+func parseSyslogMessage(data string) (*syslogMessage, error) {
+	data = strings.TrimRight(data, "\r\n\x00")
+
+	if !strings.HasPrefix(data, "<") {
+		return nil, fmt.Errorf("missing priority")
+	}
+	end := strings.IndexByte(data, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("invalid priority")
+	}
+	pri, err := strconv.Atoi(data[1:end])
+	if err != nil || pri > 191 {
+		return nil, fmt.Errorf("invalid priority '%s'", data[1:end])
+	}
+
+	msg := &syslogMessage{Facility: pri / 8, Severity: pri % 8}
+	rest := data[end+1:]
+
+	if strings.HasPrefix(rest, "1 ") {
+		return msg, parseRFC5424(msg, rest[2:])
+	}
+	parseRFC3164(msg, rest)
+	return msg, nil
+}
+
+// parseRFC5424 reads TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG], "-" is a nil value
+func parseRFC5424(msg *syslogMessage, rest string) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		var ok bool
+		fields[i], rest, ok = strings.Cut(rest, " ")
+		if !ok && i < len(fields)-1 {
+			return fmt.Errorf("truncated RFC 5424 header")
+		}
+		if fields[i] == "-" {
+			fields[i] = ""
+		}
+	}
+
+	if fields[0] != "" {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid timestamp '%s'", fields[0])
+		}
+		msg.Timestamp = t
+	}
+	msg.Hostname, msg.AppName, msg.ProcID, msg.MsgID = fields[1], fields[2], fields[3], fields[4]
+
+	sd, rest, err := parseStructuredData(rest)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = sd
+	msg.Message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff") // The message may start with a BOM
+
+	return nil
+}
+
+// parseStructuredData reads "-" or [id param="value" ...]... and returns what follows
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(s, "-") {
+		return nil, s[1:], nil
+	}
+
+	data := make(map[string]map[string]string)
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+
+		id, params := s, map[string]string{}
+		if i := strings.IndexAny(s, " ]"); i >= 0 {
+			id, s = s[:i], s[i:]
+		}
+
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			name, value, ok := strings.Cut(s, `="`)
+			if !ok {
+				return nil, "", fmt.Errorf("invalid structured data")
+			}
+
+			// Values escape '"', '\' and ']' with a backslash
+			var b strings.Builder
+			i := 0
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) && strings.IndexByte(`"\]`, value[i+1]) >= 0 {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			if i == len(value) {
+				return nil, "", fmt.Errorf("unterminated structured data value")
+			}
+
+			params[name] = b.String()
+			s = value[i+1:]
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", fmt.Errorf("unterminated structured data")
+		}
+		s = s[1:]
+		data[id] = params
+	}
+
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("invalid structured data")
+	}
+	return data, s, nil
+}
+
+// parseRFC3164 reads the best effort format of BSD syslog: [TIMESTAMP HOSTNAME] TAG[PID]: MSG
+func parseRFC3164(msg *syslogMessage, rest string) {
+	// "Mmm dd hh:mm:ss ", the day is padded with a space
+	if len(rest) >= 16 {
+		if t, err := time.Parse(time.Stamp, rest[:15]); err == nil {
+			now := time.Now()
+			msg.Timestamp = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			if msg.Timestamp.After(now.Add(24 * time.Hour)) {
+				msg.Timestamp = msg.Timestamp.AddDate(-1, 0, 0) // Sent in December, received in January
+			}
+
+			rest = rest[16:]
+			if host, after, ok := strings.Cut(rest, " "); ok && !strings.HasSuffix(host, ":") && !strings.Contains(host, "[") {
+				msg.Hostname, rest = host, after
+			}
+		}
+	}
+
+	// The tag ends at the first character that isn't alphanumeric, usually '[' or ':'
+	i := strings.IndexFunc(rest, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./", r))
+	})
+	if i > 0 && i <= 48 && (rest[i] == '[' || rest[i] == ':') {
+		msg.AppName, rest = rest[:i], rest[i:]
+
+		if strings.HasPrefix(rest, "[") {
+			if pid, after, ok := strings.Cut(rest[1:], "]"); ok {
+				msg.ProcID, rest = pid, after
+			}
+		}
+		rest = strings.TrimPrefix(rest, ":")
+	}
+
+	msg.Message = strings.TrimSpace(rest)
+}
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSyslogMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    syslogMessage
+		wantErr bool
+	}{
+		{
+			name: "RFC 5424",
+			data: "<165>1 2024-05-01T10:20:30.123Z host01 api 1234 ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Application\"] Disk is full\n",
+			want: syslogMessage{
+				Facility: 20, Severity: 5, Timestamp: time.Date(2024, 5, 1, 10, 20, 30, 123000000, time.UTC),
+				Hostname: "host01", AppName: "api", ProcID: "1234", MsgID: "ID47",
+				StructuredData: map[string]map[string]string{"exampleSDID@32473": {"iut": "3", "eventSource": "Application"}},
+				Message:        "Disk is full",
+			},
+		},
+		{
+			name: "RFC 5424 nil values and BOM",
+			data: "<11>1 - - - - - - \ufeffPanic",
+			want: syslogMessage{Facility: 1, Severity: 3, Message: "Panic"},
+		},
+		{
+			name: "RFC 5424 without message",
+			data: "<11>1 - host - - - [a@1 x=\"1\"][b@1]",
+			want: syslogMessage{
+				Facility: 1, Severity: 3, Hostname: "host",
+				StructuredData: map[string]map[string]string{"a@1": {"x": "1"}, "b@1": {}},
+			},
+		},
+		{
+			name: "RFC 3164 with tag and pid",
+			data: "<34>su[230]: 'su root' failed for lonvick on /dev/pts/8",
+			want: syslogMessage{Facility: 4, Severity: 2, AppName: "su", ProcID: "230", Message: "'su root' failed for lonvick on /dev/pts/8"},
+		},
+		{
+			name: "RFC 3164 without tag",
+			data: "<13>Something went wrong",
+			want: syslogMessage{Facility: 1, Severity: 5, Message: "Something went wrong"},
+		},
+		{name: "missing priority", data: "hello", wantErr: true},
+		{name: "priority out of range", data: "<192>hello", wantErr: true},
+		{name: "truncated RFC 5424 header", data: "<11>1 - host", wantErr: true},
+		{name: "invalid RFC 5424 timestamp", data: "<11>1 yesterday - - - - -", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSyslogMessage(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyslogMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseSyslogMessage() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRFC3164Header(t *testing.T) {
+	msg := &syslogMessage{}
+	parseRFC3164(msg, "Oct 11 22:14:15 mymachine sshd[42]: Accepted publickey")
+
+	if msg.Hostname != "mymachine" || msg.AppName != "sshd" || msg.ProcID != "42" || msg.Message != "Accepted publickey" {
+		t.Errorf("parseRFC3164() = %+v", msg)
+	}
+	if msg.Timestamp.Month() != time.October || msg.Timestamp.Day() != 11 || msg.Timestamp.Hour() != 22 {
+		t.Errorf("parseRFC3164() timestamp = %s", msg.Timestamp)
+	}
+	if msg.Timestamp.After(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("parseRFC3164() timestamp %s is in the future", msg.Timestamp)
+	}
+}
+
+func TestParseStructuredData(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     map[string]map[string]string
+		wantRest string
+		wantErr  bool
+	}{
+		{name: "nil value", data: "- message", wantRest: " message"},
+		{name: "escaped value", data: `[id@1 a="x\"y\]z\\" b="2"] msg`, want: map[string]map[string]string{"id@1": {"a": `x"y]z\`, "b": "2"}}, wantRest: " msg"},
+		{name: "unescaped backslash", data: `[id@1 path="C:\temp"]`, want: map[string]map[string]string{"id@1": {"path": `C:\temp`}}},
+		{name: "unterminated value", data: `[id@1 a="x]`, wantErr: true},
+		{name: "unterminated element", data: `[id@1 a="x"`, wantErr: true},
+		{name: "missing value", data: `[id@1 a]`, wantErr: true},
+		{name: "not structured data", data: "message", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := parseStructuredData(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStructuredData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) || rest != tt.wantRest {
+				t.Errorf("parseStructuredData() = %v, %q, want %v, %q", got, rest, tt.want, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestParseSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"emerg", 0, false},
+		{"Critical", 2, false},
+		{"warn", 4, false},
+		{"3", 3, false},
+		{"verbose", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSyslogSeverity(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSyslogSeverity(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
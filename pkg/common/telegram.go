@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"text/template"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
@@ -22,9 +24,25 @@ type TelegramProvider struct {
 }
 
 type TelegramMessage struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode"`
+	ChatID           string `json:"chat_id"`
+	Text             string `json:"text"`
+	ParseMode        string `json:"parse_mode"`
+	ReplyToMessageID int    `json:"reply_to_message_id,omitempty"`
+}
+
+// TelegramPhoto is the request of sendPhoto, Telegram downloads the photo from its URL
+type TelegramPhoto struct {
+	ChatID           string `json:"chat_id"`
+	Photo            string `json:"photo"`
+	Caption          string `json:"caption,omitempty"`
+	ReplyToMessageID int    `json:"reply_to_message_id,omitempty"`
+}
+
+// TelegramResponse is the part of the Bot API response used to reply to a message
+type TelegramResponse struct {
+	Result struct {
+		MessageID int `json:"message_id"`
+	} `json:"result"`
 }
 
 func NewTelegramProvider(cfg config.TelegramConfig, proxyConfig config.ProxyConfig) *TelegramProvider {
@@ -57,6 +75,12 @@ func (t *TelegramProvider) SendAlert(i *m.Incident) error {
 		ParseMode: "HTML",
 	}
 
+	// Reply to the group notification, when the incident belongs to a notified group
+	threadKey := "telegram:" + t.chatID
+	if ref := i.Threads[threadKey]; ref != "" {
+		telegramMsg.ReplyToMessageID, _ = strconv.Atoi(ref)
+	}
+
 	jsonData, err := json.Marshal(telegramMsg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
@@ -83,5 +107,45 @@ func (t *TelegramProvider) SendAlert(i *m.Incident) error {
 		return fmt.Errorf("telegram API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
 	}
 
+	var sent TelegramResponse
+	if err := json.Unmarshal(body, &sent); err == nil && sent.Result.MessageID != 0 {
+		recordThread(i, threadKey, strconv.Itoa(sent.Result.MessageID))
+	}
+
+	// The image follows as a reply, its caption can't be as long as a message.
+	// The alert is delivered at this point, so a failed image doesn't fail it.
+	if image := alertImage(i); image != "" {
+		if err := t.sendPhoto(image, i.Title, sent.Result.MessageID); err != nil {
+			log.Printf("Failed to send the image of incident %s to Telegram: %v", i.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendPhoto posts an image from its URL
+func (t *TelegramProvider) sendPhoto(photo, caption string, replyTo int) error {
+	jsonData, err := json.Marshal(TelegramPhoto{
+		ChatID:           t.chatID,
+		Photo:            photo,
+		Caption:          caption,
+		ReplyToMessageID: replyTo,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal photo: %w", err)
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendPhoto", t.botToken)
+	resp, err := t.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to send photo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("telegram API returned non-200 status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
 	return nil
 }
@@ -0,0 +1,194 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
+	"github.com/VersusControl/versus-incident/pkg/utils"
+)
+
+const (
+	WebhookEventTrigger     = "trigger"
+	WebhookEventAcknowledge = "acknowledge"
+	WebhookEventResolve     = "resolve"
+
+	defaultWebhookSignatureHeader = "X-Versus-Signature"
+)
+
+// WebhookOnCallPayload is the data passed to the webhook body template.
+// Without a template it is sent as-is in JSON.
+type WebhookOnCallPayload struct {
+	Event       string                 `json:"event"`
+	IncidentID  string                 `json:"incident_id"`
+	Fingerprint string                 `json:"fingerprint"`
+	TeamID      string                 `json:"team_id,omitempty"`
+	Resolved    bool                   `json:"resolved"`
+	Summary     string                 `json:"summary"`
+	Severity    string                 `json:"severity,omitempty"`
+	AckURL      string                 `json:"ack_url,omitempty"`
+	Timestamp   string                 `json:"timestamp"`
+	Content     map[string]interface{} `json:"content"`
+}
+
+// WebhookOnCallProvider implements the OnCallProvider interface by posting to any HTTP endpoint,
+// e.g. Grafana OnCall, Splunk On-Call (VictorOps) or an in-house paging service
+type WebhookOnCallProvider struct {
+	url             string
+	headers         map[string]string
+	secret          string
+	signatureHeader string
+	templatePath    string
+	client          *http.Client
+}
+
+// NewWebhookOnCallProvider creates a new webhook on-call provider
+func NewWebhookOnCallProvider(cfg config.OnCallWebhookConfig, proxyConfig config.ProxyConfig) *WebhookOnCallProvider {
+	signatureHeader := cfg.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = defaultWebhookSignatureHeader
+	}
+
+	return &WebhookOnCallProvider{
+		url:             cfg.URL,
+		headers:         cfg.Headers,
+		secret:          cfg.Secret,
+		signatureHeader: signatureHeader,
+		templatePath:    cfg.TemplatePath,
+		client:          utils.CreateHTTPClient(proxyConfig, cfg.UseProxy),
+	}
+}
+
+// TriggerOnCall posts the trigger event to the webhook
+func (p *WebhookOnCallProvider) TriggerOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	return p.send(ctx, WebhookEventTrigger, incident, cfg)
+}
+
+// AckOnCall posts the acknowledge event to the webhook
+func (p *WebhookOnCallProvider) AckOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	return p.send(ctx, WebhookEventAcknowledge, incident, cfg)
+}
+
+// ResolveOnCall posts the resolve event to the webhook
+func (p *WebhookOnCallProvider) ResolveOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error {
+	return p.send(ctx, WebhookEventResolve, incident, cfg)
+}
+
+func (p *WebhookOnCallProvider) send(ctx context.Context, event string, incident *m.Incident, cfg *config.OnCallConfig) error {
+	// Use the override config if provided, otherwise use the default
+	webhookURL := p.url
+	if cfg != nil && cfg.Webhook.URL != "" {
+		webhookURL = cfg.Webhook.URL
+	}
+
+	body, err := p.renderBody(newWebhookOnCallPayload(event, incident))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Versus-Event", event)
+	for key, value := range p.headers {
+		if value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	if p.secret != "" {
+		req.Header.Set(p.signatureHeader, "sha256="+signPayload(p.secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned non-success status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	log.Printf("On-call webhook %s event sent: %s", event, incident.ID)
+	return nil
+}
+
+// renderBody executes the body template, or encodes the payload as JSON when no template is configured
+func (p *WebhookOnCallProvider) renderBody(payload WebhookOnCallPayload) ([]byte, error) {
+	if p.templatePath == "" {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal webhook payload: %v", err)
+		}
+		return body, nil
+	}
+
+	funcMaps := utils.GetTemplateFuncMaps()
+
+	tmpl, err := template.New(filepath.Base(p.templatePath)).Funcs(funcMaps).ParseFiles(p.templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, payload); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("webhook template %s did not render valid JSON", p.templatePath)
+	}
+
+	return body.Bytes(), nil
+}
+
+func newWebhookOnCallPayload(event string, incident *m.Incident) WebhookOnCallPayload {
+	payload := WebhookOnCallPayload{
+		Event:       event,
+		IncidentID:  incident.ID,
+		Fingerprint: incident.Fingerprint,
+		TeamID:      incident.TeamID,
+		Resolved:    incident.Resolved,
+		Summary:     incidentSummary(incident),
+		Severity:    incidentSeverity(incident),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Content:     map[string]interface{}{},
+	}
+
+	if incident.Content != nil {
+		for k, v := range *incident.Content {
+			payload.Content[k] = v
+		}
+
+		if ackURL, ok := payload.Content["AckURL"].(string); ok {
+			payload.AckURL = ackURL
+			delete(payload.Content, "AckURL")
+		}
+	}
+
+	return payload
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of the body, so receivers can verify the sender
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
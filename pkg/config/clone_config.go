@@ -16,6 +16,19 @@ func cloneConfig(src *Config) *Config {
 		OnCall:     cloneOnCallConfig(src.OnCall),
 		Proxy:      cloneProxyConfig(src.Proxy),
 		Redis:      cloneRedisConfig(src.Redis),
+
+		OnCallSchedules: cloneOnCallSchedulesConfig(src.OnCallSchedules),
+
+		Routes: src.Routes, // The routing tree and teams are never changed per request, so they can be shared
+		Teams:  src.Teams,
+
+		Silences: SilencesConfig{
+			Enable:             src.Silences.Enable,
+			MaintenanceWindows: src.Silences.MaintenanceWindows,
+		},
+		InhibitRules: src.InhibitRules,
+		ResolveRules: src.ResolveRules,
+		Sources:      src.Sources,
 	}
 
 	return cloned
@@ -24,13 +37,14 @@ func cloneConfig(src *Config) *Config {
 // Helper function to deep clone the AlertConfig struct
 func cloneAlertConfig(src AlertConfig) AlertConfig {
 	return AlertConfig{
-		DebugBody: src.DebugBody,
-		Slack:     cloneSlackConfig(src.Slack),
-		Telegram:  cloneTelegramConfig(src.Telegram),
-		Viber:     cloneViberConfig(src.Viber),
-		Email:     cloneEmailConfig(src.Email),
-		MSTeams:   cloneMSTeamsConfig(src.MSTeams),
-		Lark:      cloneLarkConfig(src.Lark),
+		DebugBody:  src.DebugBody,
+		ImageHosts: src.ImageHosts, // Never modified
+		Slack:      cloneSlackConfig(src.Slack),
+		Telegram:   cloneTelegramConfig(src.Telegram),
+		Viber:      cloneViberConfig(src.Viber),
+		Email:      cloneEmailConfig(src.Email),
+		MSTeams:    cloneMSTeamsConfig(src.MSTeams),
+		Lark:       cloneLarkConfig(src.Lark),
 	}
 }
 
@@ -38,6 +52,7 @@ func cloneAlertConfig(src AlertConfig) AlertConfig {
 func cloneSlackConfig(src SlackConfig) SlackConfig {
 	return SlackConfig{
 		Enable:       src.Enable,
+		Name:         src.Name,
 		Token:        src.Token,
 		ChannelID:    src.ChannelID,
 		TemplatePath: src.TemplatePath,
@@ -46,6 +61,7 @@ func cloneSlackConfig(src SlackConfig) SlackConfig {
 			ButtonText:    src.MessageProperties.ButtonText,
 			ButtonStyle:   src.MessageProperties.ButtonStyle,
 		},
+		Instances: src.Instances, // Instances are never changed per request, so they can be shared
 	}
 }
 
@@ -53,10 +69,13 @@ func cloneSlackConfig(src SlackConfig) SlackConfig {
 func cloneTelegramConfig(src TelegramConfig) TelegramConfig {
 	return TelegramConfig{
 		Enable:       src.Enable,
+		Name:         src.Name,
 		BotToken:     src.BotToken,
 		ChatID:       src.ChatID,
 		TemplatePath: src.TemplatePath,
 		UseProxy:     src.UseProxy,
+		Proxy:        src.Proxy,
+		Instances:    src.Instances,
 	}
 }
 
@@ -64,12 +83,15 @@ func cloneTelegramConfig(src TelegramConfig) TelegramConfig {
 func cloneViberConfig(src ViberConfig) ViberConfig {
 	return ViberConfig{
 		Enable:       src.Enable,
+		Name:         src.Name,
 		APIType:      src.APIType,
 		BotToken:     src.BotToken,
 		UserID:       src.UserID,
 		TemplatePath: src.TemplatePath,
 		ChannelID:    src.ChannelID,
 		UseProxy:     src.UseProxy,
+		Proxy:        src.Proxy,
+		Instances:    src.Instances,
 	}
 }
 
@@ -77,6 +99,7 @@ func cloneViberConfig(src ViberConfig) ViberConfig {
 func cloneEmailConfig(src EmailConfig) EmailConfig {
 	return EmailConfig{
 		Enable:       src.Enable,
+		Name:         src.Name,
 		SMTPHost:     src.SMTPHost,
 		SMTPPort:     src.SMTPPort,
 		Username:     src.Username,
@@ -84,6 +107,9 @@ func cloneEmailConfig(src EmailConfig) EmailConfig {
 		To:           src.To,
 		Subject:      src.Subject,
 		TemplatePath: src.TemplatePath,
+		UseProxy:     src.UseProxy,
+		Proxy:        src.Proxy,
+		Instances:    src.Instances,
 	}
 }
 
@@ -100,9 +126,11 @@ func cloneMSTeamsConfig(src MSTeamsConfig) MSTeamsConfig {
 
 	return MSTeamsConfig{
 		Enable:           src.Enable,
+		Name:             src.Name,
 		TemplatePath:     src.TemplatePath,
 		PowerAutomateURL: src.PowerAutomateURL,
 		OtherPowerURLs:   otherPowerURLsCopy,
+		Instances:        src.Instances,
 	}
 }
 
@@ -119,21 +147,31 @@ func cloneLarkConfig(src LarkConfig) LarkConfig {
 
 	return LarkConfig{
 		Enable:           src.Enable,
+		Name:             src.Name,
 		WebhookURL:       src.WebhookURL,
 		TemplatePath:     src.TemplatePath,
 		OtherWebhookURLs: otherWebhookURLsCopy,
+		AppID:            src.AppID,
+		AppSecret:        src.AppSecret,
 		UseProxy:         src.UseProxy,
+		Proxy:            src.Proxy,
+		Instances:        src.Instances,
 	}
 }
 
 // Helper function to deep clone the QueueConfig struct
 func cloneQueueConfig(src QueueConfig) QueueConfig {
 	return QueueConfig{
-		Enable: src.Enable,
-		SNS:    cloneSNSConfig(src.SNS),
-		SQS:    cloneSQSConfig(src.SQS),
-		PubSub: clonePubSubConfig(src.PubSub),
-		AzBus:  cloneAzBusConfig(src.AzBus),
+		Enable:       src.Enable,
+		SNS:          cloneSNSConfig(src.SNS),
+		SQS:          cloneSQSConfig(src.SQS),
+		PubSub:       clonePubSubConfig(src.PubSub),
+		AzBus:        cloneAzBusConfig(src.AzBus),
+		Syslog:       src.Syslog,       // Only read at startup
+		SMTP:         src.SMTP,         // Only read at startup
+		Kafka:        src.Kafka,        // Only read at startup
+		RedisStreams: src.RedisStreams, // Only read at startup
+		NATS:         src.NATS,         // Only read at startup
 	}
 }
 
@@ -173,8 +211,12 @@ func cloneOnCallConfig(src OnCallConfig) OnCallConfig {
 		InitializedOnly:    src.InitializedOnly,
 		WaitMinutes:        src.WaitMinutes,
 		Provider:           src.Provider,
+		Providers:          append([]string(nil), src.Providers...),
+		Mode:               src.Mode,
 		AwsIncidentManager: cloneAwsIncidentManagerConfig(src.AwsIncidentManager),
 		PagerDuty:          clonePagerDutyConfig(src.PagerDuty),
+		Opsgenie:           cloneOpsgenieConfig(src.Opsgenie),
+		Webhook:            cloneOnCallWebhookConfig(src.Webhook),
 	}
 }
 
@@ -212,6 +254,66 @@ func clonePagerDutyConfig(src PagerDutyConfig) PagerDutyConfig {
 	}
 }
 
+// Helper function to deep clone the OpsgenieConfig struct
+func cloneOpsgenieConfig(src OpsgenieConfig) OpsgenieConfig {
+	// Create a copy of OtherAPIKeys map if it exists
+	var otherAPIKeysCopy map[string]string
+	if src.OtherAPIKeys != nil {
+		otherAPIKeysCopy = make(map[string]string)
+		for k, v := range src.OtherAPIKeys {
+			otherAPIKeysCopy[k] = v
+		}
+	}
+
+	// Create a copy of PriorityMapping map if it exists
+	var priorityMappingCopy map[string]string
+	if src.PriorityMapping != nil {
+		priorityMappingCopy = make(map[string]string)
+		for k, v := range src.PriorityMapping {
+			priorityMappingCopy[k] = v
+		}
+	}
+
+	return OpsgenieConfig{
+		APIKey:          src.APIKey,
+		Region:          src.Region,
+		APIURL:          src.APIURL,
+		OtherAPIKeys:    otherAPIKeysCopy,
+		PriorityMapping: priorityMappingCopy,
+	}
+}
+
+// Helper function to deep clone the OnCallWebhookConfig struct
+func cloneOnCallWebhookConfig(src OnCallWebhookConfig) OnCallWebhookConfig {
+	// Create a copy of OtherURLs map if it exists
+	var otherURLsCopy map[string]string
+	if src.OtherURLs != nil {
+		otherURLsCopy = make(map[string]string)
+		for k, v := range src.OtherURLs {
+			otherURLsCopy[k] = v
+		}
+	}
+
+	// Create a copy of Headers map if it exists
+	var headersCopy map[string]string
+	if src.Headers != nil {
+		headersCopy = make(map[string]string)
+		for k, v := range src.Headers {
+			headersCopy[k] = v
+		}
+	}
+
+	return OnCallWebhookConfig{
+		URL:             src.URL,
+		OtherURLs:       otherURLsCopy,
+		Headers:         headersCopy,
+		Secret:          src.Secret,
+		SignatureHeader: src.SignatureHeader,
+		TemplatePath:    src.TemplatePath,
+		UseProxy:        src.UseProxy,
+	}
+}
+
 // Helper function to deep clone the ProxyConfig struct
 func cloneProxyConfig(src ProxyConfig) ProxyConfig {
 	return ProxyConfig{
@@ -231,3 +333,17 @@ func cloneRedisConfig(src RedisConfig) RedisConfig {
 		InsecureSkipVerify: src.InsecureSkipVerify,
 	}
 }
+
+// Helper function to clone the OnCallSchedulesConfig struct
+// Users and schedules are read-only after loading, so the slices are shared
+func cloneOnCallSchedulesConfig(src OnCallSchedulesConfig) OnCallSchedulesConfig {
+	return OnCallSchedulesConfig{
+		Enable:    src.Enable,
+		Default:   src.Default,
+		Users:     src.Users,
+		Schedules: src.Schedules,
+
+		CalendarToken: src.CalendarToken,
+		CalendarDays:  src.CalendarDays,
+	}
+}
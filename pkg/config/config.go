@@ -22,6 +22,18 @@ type Config struct {
 	Proxy          ProxyConfig
 	ScheduledAlert ScheduledAlertConfig `mapstructure:"scheduled_alert"`
 
+	OnCallSchedules OnCallSchedulesConfig `mapstructure:"oncall_schedules"`
+
+	Routes []RouteConfig `mapstructure:"routes"`
+	Teams  []TeamConfig  `mapstructure:"teams"`
+
+	Silences     SilencesConfig      `mapstructure:"silences"`
+	InhibitRules []InhibitRuleConfig `mapstructure:"inhibit_rules"`
+
+	ResolveRules ResolveRulesConfig `mapstructure:"resolve_rules"`
+
+	Sources map[string]SourceConfig `mapstructure:"sources"` // Settings of the /api/sources endpoints, by source
+
 	Redis RedisConfig `mapstructure:"redis"`
 }
 
@@ -32,21 +44,24 @@ type ProxyConfig struct {
 }
 
 type AlertConfig struct {
-	DebugBody bool `mapstructure:"debug_body"`
-	Slack     SlackConfig
-	Telegram  TelegramConfig
-	Viber     ViberConfig
-	Email     EmailConfig
-	MSTeams   MSTeamsConfig
-	Lark      LarkConfig
+	DebugBody  bool     `mapstructure:"debug_body"`
+	ImageHosts []string `mapstructure:"image_hosts"` // Optional: hosts alert images are downloaded from, for email and Lark
+	Slack      SlackConfig
+	Telegram   TelegramConfig
+	Viber      ViberConfig
+	Email      EmailConfig
+	MSTeams    MSTeamsConfig
+	Lark       LarkConfig
 }
 
 type SlackConfig struct {
 	Enable            bool
+	Name              string `mapstructure:"name"` // Instance name, selected with ?slack_instance=
 	Token             string
 	ChannelID         string                 `mapstructure:"channel_id"`
 	TemplatePath      string                 `mapstructure:"template_path"`
 	MessageProperties SlackMessageProperties `mapstructure:"message_properties"`
+	Instances         []SlackConfig          `mapstructure:"instances"` // Optional named instances with their own credentials and template
 }
 
 type SlackMessageProperties struct {
@@ -57,58 +72,80 @@ type SlackMessageProperties struct {
 
 type TelegramConfig struct {
 	Enable       bool
-	BotToken     string `mapstructure:"bot_token"`
-	ChatID       string `mapstructure:"chat_id"`
-	TemplatePath string `mapstructure:"template_path"`
-	UseProxy     bool   `mapstructure:"use_proxy"`
+	Name         string           `mapstructure:"name"` // Instance name, selected with ?telegram_instance=
+	BotToken     string           `mapstructure:"bot_token"`
+	ChatID       string           `mapstructure:"chat_id"`
+	TemplatePath string           `mapstructure:"template_path"`
+	UseProxy     bool             `mapstructure:"use_proxy"`
+	Proxy        ProxyConfig      `mapstructure:"proxy"`     // Optional: overrides the global proxy
+	Instances    []TelegramConfig `mapstructure:"instances"` // Optional named instances with their own credentials and template
 }
 
 type ViberConfig struct {
 	Enable  bool
+	Name    string `mapstructure:"name"`     // Instance name, selected with ?viber_instance=
 	APIType string `mapstructure:"api_type"` // "bot" or "channel" - defaults to "channel"
 	// Bot API configuration
 	BotToken     string `mapstructure:"bot_token"`
 	UserID       string `mapstructure:"user_id"`
 	TemplatePath string `mapstructure:"template_path"`
 	// Channel configuration for Channels Post API
-	ChannelID string `mapstructure:"channel_id"`
-	UseProxy  bool   `mapstructure:"use_proxy"`
+	ChannelID string        `mapstructure:"channel_id"`
+	UseProxy  bool          `mapstructure:"use_proxy"`
+	Proxy     ProxyConfig   `mapstructure:"proxy"`     // Optional: overrides the global proxy
+	Instances []ViberConfig `mapstructure:"instances"` // Optional named instances with their own credentials and template
 }
 
 type EmailConfig struct {
 	Enable       bool
+	Name         string `mapstructure:"name"` // Instance name, selected with ?email_instance=
 	SMTPHost     string `mapstructure:"smtp_host"`
 	SMTPPort     string `mapstructure:"smtp_port"`
 	Username     string
 	Password     string
 	To           string
 	Subject      string
-	TemplatePath string `mapstructure:"template_path"`
+	TemplatePath string        `mapstructure:"template_path"`
+	UseProxy     bool          `mapstructure:"use_proxy"` // Downloads of alert images only, SMTP doesn't use the proxy
+	Proxy        ProxyConfig   `mapstructure:"proxy"`     // Optional: overrides the global proxy
+	Instances    []EmailConfig `mapstructure:"instances"` // Optional named instances with their own SMTP server and template
 }
 
 type MSTeamsConfig struct {
 	Enable         bool
+	Name           string            `mapstructure:"name"` // Instance name, selected with ?msteams_instance=
 	TemplatePath   string            `mapstructure:"template_path"`
 	OtherPowerURLs map[string]string `mapstructure:"other_power_urls"` // Optional alternative Power Automate URLs
 	// Power Automate Workflow URL for Teams integration
-	PowerAutomateURL string `mapstructure:"power_automate_url"`
+	PowerAutomateURL string          `mapstructure:"power_automate_url"`
+	Instances        []MSTeamsConfig `mapstructure:"instances"` // Optional named instances with their own URL and template
 }
 
 type LarkConfig struct {
 	Enable           bool
+	Name             string            `mapstructure:"name"` // Instance name, selected with ?lark_instance=
 	WebhookURL       string            `mapstructure:"webhook_url"`
 	TemplatePath     string            `mapstructure:"template_path"`
 	OtherWebhookURLs map[string]string `mapstructure:"other_webhook_urls"`
+	AppID            string            `mapstructure:"app_id"`     // Optional: app credentials to upload alert images, e.g. Grafana panels
+	AppSecret        string            `mapstructure:"app_secret"` // Optional
 	UseProxy         bool              `mapstructure:"use_proxy"`
+	Proxy            ProxyConfig       `mapstructure:"proxy"`     // Optional: overrides the global proxy
+	Instances        []LarkConfig      `mapstructure:"instances"` // Optional named instances with their own URL and template
 }
 
 type QueueConfig struct {
-	Enable    bool         `mapstructure:"enable"`
-	DebugBody bool         `mapstructure:"debug_body"`
-	SNS       SNSConfig    `mapstructure:"sns"`
-	SQS       SQSConfig    `mapstructure:"sqs"`
-	PubSub    PubSubConfig `mapstructure:"pubsub"`
-	AzBus     AzBusConfig  `mapstructure:"azbus"`
+	Enable       bool               `mapstructure:"enable"`
+	DebugBody    bool               `mapstructure:"debug_body"`
+	SNS          SNSConfig          `mapstructure:"sns"`
+	SQS          SQSConfig          `mapstructure:"sqs"`
+	PubSub       PubSubConfig       `mapstructure:"pubsub"`
+	AzBus        AzBusConfig        `mapstructure:"azbus"`
+	Syslog       SyslogConfig       `mapstructure:"syslog"`
+	SMTP         SMTPConfig         `mapstructure:"smtp"`
+	Kafka        KafkaConfig        `mapstructure:"kafka"`
+	RedisStreams RedisStreamsConfig `mapstructure:"redis_streams"`
+	NATS         NATSConfig         `mapstructure:"nats"`
 }
 
 type SNSConfig struct {
@@ -131,13 +168,123 @@ type AzBusConfig struct {
 	Enable bool `mapstructure:"enable"`
 }
 
+// SyslogConfig receives RFC 5424 and RFC 3164 messages, e.g. from network gear
+type SyslogConfig struct {
+	Enable     bool            `mapstructure:"enable"`
+	Address    string          `mapstructure:"address"`    // Defaults to ":514"
+	Protocols  []string        `mapstructure:"protocols"`  // "udp" and/or "tcp", defaults to [udp]
+	TLS        SyslogTLSConfig `mapstructure:"tls"`        // Optional: TLS on TCP, RFC 5425
+	Severity   string          `mapstructure:"severity"`   // Least severe level that creates incidents, e.g. "err", defaults to "warning"
+	Facilities []string        `mapstructure:"facilities"` // Optional: only these facilities, e.g. [daemon, local0]
+	AppNames   []string        `mapstructure:"app_names"`  // Optional: only these app names, e.g. [sshd]
+}
+
+type SyslogTLSConfig struct {
+	Enable       bool   `mapstructure:"enable"`
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // Optional: require client certificates signed by this CA
+}
+
+// SMTPConfig receives alert emails, e.g. from backup tools and status pages that only send emails
+type SMTPConfig struct {
+	Enable         bool                  `mapstructure:"enable"`
+	Address        string                `mapstructure:"address"`          // Defaults to ":2525"
+	Domain         string                `mapstructure:"domain"`           // Optional: name in the greeting, defaults to the hostname
+	MaxMessageSize int                   `mapstructure:"max_message_size"` // Bytes, defaults to 10MB
+	AllowedSenders []string              `mapstructure:"allowed_senders"`  // Optional: addresses or domains, e.g. [backup@vendor.com, "@statuspage.io"]
+	Recipients     []SMTPRecipientConfig `mapstructure:"recipients"`       // Optional: team of the emails sent to an address
+	Extract        []SMTPExtractConfig   `mapstructure:"extract"`          // Optional: labels extracted with regular expressions
+}
+
+type SMTPRecipientConfig struct {
+	Address string `mapstructure:"address"` // Address or domain, e.g. backup@alerts.example.com or "@payments.example.com"
+	Team    string `mapstructure:"team"`
+}
+
+type SMTPExtractConfig struct {
+	Field   string `mapstructure:"field"`   // Label of the first group or the match, not needed with named groups
+	Pattern string `mapstructure:"pattern"` // Regular expression, each named group is a label
+	From    string `mapstructure:"from"`    // "subject", "body" or a header, e.g. "X-Priority", defaults to the subject then the body
+}
+
+// KafkaConfig consumes alert events from Kafka topics with a consumer group
+type KafkaConfig struct {
+	Enable          bool            `mapstructure:"enable"`
+	Brokers         []string        `mapstructure:"brokers"`           // e.g. [kafka-1:9092, kafka-2:9092], or one comma-separated entry
+	Topics          []string        `mapstructure:"topics"`            // e.g. [alerts]
+	GroupID         string          `mapstructure:"group_id"`          // Defaults to "versus-incident"
+	StartOffset     string          `mapstructure:"start_offset"`      // "latest" (default) or "earliest", for a group without committed offsets
+	Concurrency     int             `mapstructure:"concurrency"`       // Messages handled at once per partition, defaults to 1 (in order)
+	MaxRetries      int             `mapstructure:"max_retries"`       // Attempts after a failure before the partition stops, defaults to 3
+	DeadLetterTopic string          `mapstructure:"dead_letter_topic"` // Optional: topic of the messages that failed max_retries times, instead of stopping
+	JSONPath        string          `mapstructure:"json_path"`         // Optional: path of the incident in an envelope, e.g. "$.detail.alert"
+	SASL            KafkaSASLConfig `mapstructure:"sasl"`
+	TLS             KafkaTLSConfig  `mapstructure:"tls"`
+}
+
+type KafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism"` // "plain", "scram-sha-256" or "scram-sha-512", no SASL when empty
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+}
+
+type KafkaTLSConfig struct {
+	Enable             bool   `mapstructure:"enable"`
+	CAFile             string `mapstructure:"ca_file"`   // Optional: defaults to the system CAs
+	CertFile           string `mapstructure:"cert_file"` // Optional: client certificate
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// RedisStreamsConfig consumes the incidents added to Redis streams with XADD, over the connection of the redis section
+type RedisStreamsConfig struct {
+	Enable        bool     `mapstructure:"enable"`
+	Streams       []string `mapstructure:"streams"`        // Defaults to [versus:incidents]
+	Group         string   `mapstructure:"group"`          // Defaults to "versus-incident"
+	Consumer      string   `mapstructure:"consumer"`       // Defaults to the hostname, must differ between replicas
+	Field         string   `mapstructure:"field"`          // Field of the entry with the incident as JSON, defaults to "payload"
+	BatchSize     int      `mapstructure:"batch_size"`     // Entries read at once, defaults to 10
+	ClaimIdle     string   `mapstructure:"claim_idle"`     // Pending entries idle this long are claimed again, defaults to 5m
+	MaxDeliveries int      `mapstructure:"max_deliveries"` // Deliveries before a failing entry is dropped, defaults to 5
+}
+
+// NATSConfig consumes alert events from a JetStream stream with a durable consumer
+type NATSConfig struct {
+	Enable            bool          `mapstructure:"enable"`
+	URL               string        `mapstructure:"url"`                 // e.g. nats://localhost:4222, comma-separated for a cluster
+	Stream            string        `mapstructure:"stream"`              // JetStream stream with the alert subjects
+	Consumer          string        `mapstructure:"consumer"`            // Durable consumer, defaults to "versus-incident"
+	Subjects          []string      `mapstructure:"subjects"`            // Optional: filter subjects, e.g. [alerts.>], defaults to every subject of the stream
+	DeliverPolicy     string        `mapstructure:"deliver_policy"`      // "new" (default) or "all", when the consumer is created
+	AckWait           string        `mapstructure:"ack_wait"`            // Redelivered when not acknowledged for this long, defaults to 30s
+	MaxDeliver        int           `mapstructure:"max_deliver"`         // Deliveries before a failing message is dead-lettered, defaults to 5
+	DeadLetterSubject string        `mapstructure:"dead_letter_subject"` // Optional: subject of the messages that failed max_deliver times, must belong to a stream
+	CredsFile         string        `mapstructure:"creds_file"`          // Optional: NATS credentials, e.g. for NGS
+	Token             string        `mapstructure:"token"`               // Optional
+	Username          string        `mapstructure:"username"`            // Optional
+	Password          string        `mapstructure:"password"`
+	TLS               NATSTLSConfig `mapstructure:"tls"`
+}
+
+type NATSTLSConfig struct {
+	Enable   bool   `mapstructure:"enable"`
+	CAFile   string `mapstructure:"ca_file"`   // Optional: defaults to the system CAs
+	CertFile string `mapstructure:"cert_file"` // Optional: client certificate
+	KeyFile  string `mapstructure:"key_file"`
+}
+
 type OnCallConfig struct {
 	Enable             bool
 	InitializedOnly    bool                     `mapstructure:"initialized_only"` // Initialize infrastructure but don't enable by default
 	WaitMinutes        int                      `mapstructure:"wait_minutes"`
-	Provider           string                   `mapstructure:"provider"` // "aws_incident_manager" or "pagerduty"
+	Provider           string                   `mapstructure:"provider"`  // "aws_incident_manager", "pagerduty", "opsgenie" or "webhook"
+	Providers          []string                 `mapstructure:"providers"` // Optional: page several providers, takes precedence over provider
+	Mode               string                   `mapstructure:"mode"`      // "all" (default) or "failover", used with providers
 	AwsIncidentManager AwsIncidentManagerConfig `mapstructure:"aws_incident_manager"`
 	PagerDuty          PagerDutyConfig          `mapstructure:"pagerduty"`
+	Opsgenie           OpsgenieConfig           `mapstructure:"opsgenie"`
+	Webhook            OnCallWebhookConfig      `mapstructure:"webhook"`
 }
 
 type AwsIncidentManagerConfig struct {
@@ -150,6 +297,24 @@ type PagerDutyConfig struct {
 	OtherRoutingKeys map[string]string `mapstructure:"other_routing_keys"`
 }
 
+type OpsgenieConfig struct {
+	APIKey          string            `mapstructure:"api_key"`
+	Region          string            `mapstructure:"region"`           // "us" or "eu" - defaults to "us"
+	APIURL          string            `mapstructure:"api_url"`          // Optional: override the API base URL, e.g. for testing
+	OtherAPIKeys    map[string]string `mapstructure:"other_api_keys"`   // Optional alternative API keys
+	PriorityMapping map[string]string `mapstructure:"priority_mapping"` // Optional severity -> priority (P1-P5) overrides
+}
+
+type OnCallWebhookConfig struct {
+	URL             string            `mapstructure:"url"`
+	OtherURLs       map[string]string `mapstructure:"other_urls"`       // Optional alternative webhook URLs
+	Headers         map[string]string `mapstructure:"headers"`          // Optional custom headers, e.g. Authorization
+	Secret          string            `mapstructure:"secret"`           // Optional HMAC-SHA256 signing secret
+	SignatureHeader string            `mapstructure:"signature_header"` // Defaults to "X-Versus-Signature"
+	TemplatePath    string            `mapstructure:"template_path"`    // Optional JSON body template
+	UseProxy        bool              `mapstructure:"use_proxy"`
+}
+
 type RedisConfig struct {
 	Host               string `mapstructure:"host"`
 	Port               int    `mapstructure:"port"`
@@ -191,6 +356,160 @@ type ScheduledChannelsConfig struct {
 	EmailTo           string `mapstructure:"email_to"`
 }
 
+// OnCallSchedulesConfig holds the built-in on-call schedules and rotations
+type OnCallSchedulesConfig struct {
+	Enable    bool               `mapstructure:"enable"`
+	Default   string             `mapstructure:"default"` // Schedule exposed to templates as .OnCall
+	Users     []OnCallUserConfig `mapstructure:"users"`
+	Schedules []ScheduleConfig   `mapstructure:"schedules"`
+
+	CalendarToken string `mapstructure:"calendar_token"` // Required as ?token= by the iCalendar feed
+	CalendarDays  int    `mapstructure:"calendar_days"`  // Days of upcoming shifts in the iCalendar feed
+}
+
+// OnCallUserConfig describes a person that can be on call
+type OnCallUserConfig struct {
+	ID          string `mapstructure:"id" json:"id"`
+	Name        string `mapstructure:"name" json:"name"`
+	Email       string `mapstructure:"email" json:"email,omitempty"`
+	SlackUserID string `mapstructure:"slack_user_id" json:"slack_user_id,omitempty"`
+	Phone       string `mapstructure:"phone" json:"phone,omitempty"`
+}
+
+// ScheduleConfig describes an on-call schedule made of rotation layers and overrides
+type ScheduleConfig struct {
+	Name          string                   `mapstructure:"name" json:"name"`
+	Timezone      string                   `mapstructure:"timezone" json:"timezone,omitempty"`             // e.g., "Asia/Ho_Chi_Minh", defaults to local
+	NotifyHandoff bool                     `mapstructure:"notify_handoff" json:"notify_handoff,omitempty"` // Notify the incoming person at each handoff
+	Layers        []ScheduleLayerConfig    `mapstructure:"layers" json:"layers"`                           // Later layers take precedence over earlier ones
+	Overrides     []ScheduleOverrideConfig `mapstructure:"overrides" json:"overrides,omitempty"`
+}
+
+// ScheduleLayerConfig describes one rotation of users
+type ScheduleLayerConfig struct {
+	Name          string   `mapstructure:"name" json:"name"`
+	Rotation      string   `mapstructure:"rotation" json:"rotation"`                       // "daily" or "weekly"
+	Start         string   `mapstructure:"start" json:"start"`                             // First day of the rotation, e.g. "2025-01-06"
+	HandoffTime   string   `mapstructure:"handoff_time" json:"handoff_time,omitempty"`     // e.g. "09:00", defaults to "00:00"
+	HandoffDay    string   `mapstructure:"handoff_day" json:"handoff_day,omitempty"`       // Weekly rotations only, e.g. "monday"
+	Users         []string `mapstructure:"users" json:"users"`                             // User IDs in rotation order
+	RestrictStart string   `mapstructure:"restrict_start" json:"restrict_start,omitempty"` // Optional daily window start, e.g. "18:00"
+	RestrictEnd   string   `mapstructure:"restrict_end" json:"restrict_end,omitempty"`     // Optional daily window end, e.g. "09:00"
+}
+
+// ScheduleOverrideConfig temporarily replaces whoever is on call
+type ScheduleOverrideConfig struct {
+	User  string `mapstructure:"user" json:"user"`
+	Start string `mapstructure:"start" json:"start"` // RFC 3339, or "2006-01-02T15:04" in the schedule timezone
+	End   string `mapstructure:"end" json:"end"`
+}
+
+// RouteConfig describes a node of the routing tree, child routes inherit the settings of their parent
+type RouteConfig struct {
+	Name              string             `mapstructure:"name" json:"name"`
+	Matchers          []string           `mapstructure:"matchers" json:"matchers,omitempty"`                       // e.g. severity="critical", team=~"db|infra", env!="dev"
+	Providers         []string           `mapstructure:"providers" json:"providers,omitempty"`                     // Alert providers to notify, e.g. [slack, email]
+	Channels          map[string]string  `mapstructure:"channels" json:"channels,omitempty"`                       // Same keys as the query parameters, e.g. slack_channel_id
+	Templates         map[string]string  `mapstructure:"templates" json:"templates,omitempty"`                     // Provider -> template path
+	OnCall            *RouteOnCallConfig `mapstructure:"oncall" json:"oncall,omitempty"`                           // Escalation policy
+	Storm             *StormConfig       `mapstructure:"storm" json:"storm,omitempty"`                             // Switch to digests when the route receives too many incidents
+	GroupBy           []string           `mapstructure:"group_by" json:"group_by,omitempty"`                       // Fields of the incidents notified together, e.g. [AlarmName]
+	GroupWait         string             `mapstructure:"group_wait" json:"group_wait,omitempty"`                   // Wait before the first notification of a group, defaults to 30s
+	GroupInterval     string             `mapstructure:"group_interval" json:"group_interval,omitempty"`           // Wait between the thread updates of a group, defaults to 5m
+	SplitAlerts       *bool              `mapstructure:"split_alerts" json:"split_alerts,omitempty"`               // One incident per alert of Alertmanager and Grafana payloads
+	AutoResolveAfter  string             `mapstructure:"auto_resolve_after" json:"auto_resolve_after,omitempty"`   // Resolve the incidents without a new notification for this long, e.g. "4h"
+	AutoResolveNotify bool               `mapstructure:"auto_resolve_notify" json:"auto_resolve_notify,omitempty"` // Post a message to the channels of the route when resolving them
+	Continue          bool               `mapstructure:"continue" json:"continue,omitempty"`                       // Keep matching the next sibling routes
+	Routes            []RouteConfig      `mapstructure:"routes" json:"routes,omitempty"`
+}
+
+// RouteOnCallConfig overrides the on-call settings for the incidents of a route
+type RouteOnCallConfig struct {
+	Enable      *bool    `mapstructure:"enable" json:"enable,omitempty"`
+	WaitMinutes *int     `mapstructure:"wait_minutes" json:"wait_minutes,omitempty"`
+	Providers   []string `mapstructure:"providers" json:"providers,omitempty"`
+	Mode        string   `mapstructure:"mode" json:"mode,omitempty"`
+	Schedule    string   `mapstructure:"schedule" json:"schedule,omitempty"` // On-call schedule exposed to templates
+}
+
+// TeamConfig holds the settings of a team, used for the incidents sent to /api/teams/:team/incidents
+type TeamConfig struct {
+	ID                string             `mapstructure:"id" json:"id"`
+	Providers         []string           `mapstructure:"providers" json:"providers,omitempty"` // Alert providers to notify, e.g. [slack, email]
+	Channels          map[string]string  `mapstructure:"channels" json:"channels,omitempty"`   // Same keys as the query parameters, e.g. slack_channel_id
+	Templates         map[string]string  `mapstructure:"templates" json:"templates,omitempty"` // Provider -> template path
+	OnCall            *RouteOnCallConfig `mapstructure:"oncall" json:"oncall,omitempty"`
+	QuietHours        *QuietHoursConfig  `mapstructure:"quiet_hours" json:"quiet_hours,omitempty"`
+	Storm             *StormConfig       `mapstructure:"storm" json:"storm,omitempty"`
+	GroupBy           []string           `mapstructure:"group_by" json:"group_by,omitempty"`
+	GroupWait         string             `mapstructure:"group_wait" json:"group_wait,omitempty"`
+	GroupInterval     string             `mapstructure:"group_interval" json:"group_interval,omitempty"`
+	SplitAlerts       *bool              `mapstructure:"split_alerts" json:"split_alerts,omitempty"`
+	AutoResolveAfter  string             `mapstructure:"auto_resolve_after" json:"auto_resolve_after,omitempty"`
+	AutoResolveNotify bool               `mapstructure:"auto_resolve_notify" json:"auto_resolve_notify,omitempty"`
+}
+
+// StormConfig batches the incidents of a route into digests while it receives more than threshold incidents per window
+type StormConfig struct {
+	Threshold      int               `mapstructure:"threshold" json:"threshold"`                       // Incidents within the window that start a storm
+	Window         string            `mapstructure:"window" json:"window,omitempty"`                   // e.g. "1m", defaults to 1m
+	DigestInterval string            `mapstructure:"digest_interval" json:"digest_interval,omitempty"` // e.g. "5m", defaults to 1m
+	Templates      map[string]string `mapstructure:"templates" json:"templates,omitempty"`             // Provider -> digest template path
+}
+
+// QuietHoursConfig describes when a team doesn't want to be paged
+type QuietHoursConfig struct {
+	Timezone          string   `mapstructure:"timezone" json:"timezone,omitempty"`                     // e.g. "Asia/Ho_Chi_Minh", defaults to local
+	Start             string   `mapstructure:"start" json:"start,omitempty"`                           // e.g. "22:00"
+	End               string   `mapstructure:"end" json:"end,omitempty"`                               // e.g. "07:00", wraps around midnight when before start
+	Days              []string `mapstructure:"days" json:"days,omitempty"`                             // Optional: whole quiet days, e.g. [saturday, sunday]
+	Except            []string `mapstructure:"except" json:"except,omitempty"`                         // Matchers of alerts that still page, e.g. severity="critical"
+	MuteNotifications bool     `mapstructure:"mute_notifications" json:"mute_notifications,omitempty"` // Also skip the alert notifications, not only on-call
+}
+
+// SilencesConfig holds the silences API and the recurring maintenance windows
+type SilencesConfig struct {
+	Enable             bool                      `mapstructure:"enable"`
+	MaintenanceWindows []MaintenanceWindowConfig `mapstructure:"maintenance_windows"`
+}
+
+// MaintenanceWindowConfig mutes the matching incidents on a recurring schedule
+type MaintenanceWindowConfig struct {
+	Name     string   `mapstructure:"name" json:"name"`
+	Schedule string   `mapstructure:"schedule" json:"schedule"`           // Cron expression of the start, e.g. "0 2 * * SUN"
+	Duration string   `mapstructure:"duration" json:"duration"`           // e.g. "2h"
+	Timezone string   `mapstructure:"timezone" json:"timezone,omitempty"` // e.g. "Asia/Ho_Chi_Minh", defaults to local
+	Matchers []string `mapstructure:"matchers" json:"matchers,omitempty"` // Optional: every incident when empty
+	Comment  string   `mapstructure:"comment" json:"comment,omitempty"`
+}
+
+// InhibitRuleConfig suppresses the target incidents while a source incident is firing
+type InhibitRuleConfig struct {
+	Name           string   `mapstructure:"name" json:"name"`
+	SourceMatchers []string `mapstructure:"source_matchers" json:"source_matchers"` // e.g. alertname="NodeDown"
+	TargetMatchers []string `mapstructure:"target_matchers" json:"target_matchers"` // e.g. severity=~"warning|info"
+	Equal          []string `mapstructure:"equal" json:"equal,omitempty"`           // Fields that must have the same value, e.g. [node]
+}
+
+// ResolveRulesConfig decides which payloads are resolved notifications
+type ResolveRulesConfig struct {
+	Presets []string            `mapstructure:"presets"` // Built-in rules, e.g. [default, cloudwatch], all of them when empty
+	Rules   []ResolveRuleConfig `mapstructure:"rules"`
+}
+
+// ResolveRuleConfig marks a payload as resolved when one of the fields has one of the values
+type ResolveRuleConfig struct {
+	Name     string   `mapstructure:"name" json:"name"`
+	Fields   []string `mapstructure:"fields" json:"fields"`               // Paths into the payload, e.g. data.essentials.monitorCondition
+	Values   []string `mapstructure:"values" json:"values"`               // Case-insensitive, e.g. [resolved, ok]
+	Matchers []string `mapstructure:"matchers" json:"matchers,omitempty"` // Optional: payloads the rule applies to, e.g. source="azure"
+}
+
+// SourceConfig is the settings of the endpoint of a source, e.g. /api/sources/azure
+type SourceConfig struct {
+	Token string `mapstructure:"token"` // Optional: required as ?token= or a bearer token, for sources that can't set headers
+}
+
 var (
 	cfg     *Config
 	cfgOnce sync.Once
@@ -213,8 +532,21 @@ func LoadConfig(path string) error {
 		}
 
 		for _, k := range v.AllKeys() {
-			if value, ok := v.Get(k).(string); ok {
+			switch value := v.Get(k).(type) {
+			case string:
 				v.Set(k, os.ExpandEnv(value))
+			case []interface{}:
+				// Lists aren't split into keys, e.g. named instances and routes
+				v.Set(k, expandEnv(value))
+			}
+		}
+
+		// A list of named instances, e.g. "slack: [{name: payments, ...}]",
+		// is a shorthand for "enable: true" with "instances"
+		for _, provider := range []string{"slack", "telegram", "viber", "email", "msteams", "lark"} {
+			key := "alert." + provider
+			if instances, ok := v.Get(key).([]interface{}); ok {
+				v.Set(key, map[string]interface{}{"enable": true, "instances": instances})
 			}
 		}
 
@@ -247,18 +579,51 @@ func LoadConfig(path string) error {
 		setEnableFromEnv("LARK_ENABLE", &cfg.Alert.Lark.Enable)
 		setEnableFromEnv("LARK_USE_PROXY", &cfg.Alert.Lark.UseProxy)
 		setEnableFromEnv("SNS_ENABLE", &cfg.Queue.SNS.Enable)
+		setEnableFromEnv("SYSLOG_ENABLE", &cfg.Queue.Syslog.Enable)
+		setEnableFromEnv("SMTP_ENABLE", &cfg.Queue.SMTP.Enable)
+		setEnableFromEnv("KAFKA_ENABLE", &cfg.Queue.Kafka.Enable)
+		setEnableFromEnv("REDIS_STREAMS_ENABLE", &cfg.Queue.RedisStreams.Enable)
+		setEnableFromEnv("NATS_ENABLE", &cfg.Queue.NATS.Enable)
 
 		setEnableFromEnv("ONCALL_ENABLE", &cfg.OnCall.Enable)
+		setEnableFromEnv("ONCALL_SCHEDULES_ENABLE", &cfg.OnCallSchedules.Enable)
+		setEnableFromEnv("SILENCES_ENABLE", &cfg.Silences.Enable)
 
 		// Set provider from environment variable if provided
 		if provider := os.Getenv("ONCALL_PROVIDER"); provider != "" {
 			cfg.OnCall.Provider = provider
 		}
+
+		if providers := os.Getenv("ONCALL_PROVIDERS"); providers != "" {
+			cfg.OnCall.Providers = splitList(providers)
+		}
+
+		if mode := os.Getenv("ONCALL_MODE"); mode != "" {
+			cfg.OnCall.Mode = mode
+		}
+
+		if err = ValidateOnCallMode(cfg.OnCall.Mode); err != nil {
+			err = fmt.Errorf("oncall: %w", err)
+			return
+		}
+
+		if err = cfg.validateInstances(); err != nil {
+			return
+		}
 	})
 
 	return err
 }
 
+// ValidateOnCallMode rejects the modes other than "all" and "failover", empty means "all"
+func ValidateOnCallMode(mode string) error {
+	switch mode {
+	case "", "all", "failover":
+		return nil
+	}
+	return fmt.Errorf("invalid mode '%s', expected all or failover", mode)
+}
+
 func GetConfig() *Config {
 	if cfg == nil {
 		panic("config not initialized - call Load first")
@@ -270,6 +635,14 @@ func GetConfigWitParamsOverwrite(paramsOverwrite *map[string]string) *Config {
 	// Clone the global cfg
 	clonedCfg := cloneConfig(cfg)
 
+	// Select named instances first, so the other parameters apply to them
+	clonedCfg.Alert.Slack = clonedCfg.Alert.Slack.Instance((*paramsOverwrite)["slack_instance"])
+	clonedCfg.Alert.Telegram = clonedCfg.Alert.Telegram.Instance((*paramsOverwrite)["telegram_instance"])
+	clonedCfg.Alert.Viber = clonedCfg.Alert.Viber.Instance((*paramsOverwrite)["viber_instance"])
+	clonedCfg.Alert.Email = clonedCfg.Alert.Email.Instance((*paramsOverwrite)["email_instance"])
+	clonedCfg.Alert.MSTeams = clonedCfg.Alert.MSTeams.Instance((*paramsOverwrite)["msteams_instance"])
+	clonedCfg.Alert.Lark = clonedCfg.Alert.Lark.Instance((*paramsOverwrite)["lark_instance"])
+
 	if v := (*paramsOverwrite)["slack_channel_id"]; v != "" {
 		clonedCfg.Alert.Slack.ChannelID = v
 	}
@@ -326,6 +699,18 @@ func GetConfigWitParamsOverwrite(paramsOverwrite *map[string]string) *Config {
 		}
 	}
 
+	if v := (*paramsOverwrite)["oncall_providers"]; v != "" {
+		clonedCfg.OnCall.Providers = splitList(v)
+	}
+
+	if v := (*paramsOverwrite)["oncall_mode"]; v != "" {
+		clonedCfg.OnCall.Mode = v
+	}
+
+	if v := (*paramsOverwrite)["oncall_schedule"]; v != "" {
+		clonedCfg.OnCallSchedules.Default = v
+	}
+
 	if v := (*paramsOverwrite)["awsim_other_response_plan"]; v != "" {
 		if clonedCfg.OnCall.AwsIncidentManager.OtherResponsePlanArns != nil {
 			responsePlanArn := clonedCfg.OnCall.AwsIncidentManager.OtherResponsePlanArns[v]
@@ -346,5 +731,80 @@ func GetConfigWitParamsOverwrite(paramsOverwrite *map[string]string) *Config {
 		}
 	}
 
+	if v := (*paramsOverwrite)["opsgenie_other_api_key"]; v != "" {
+		if clonedCfg.OnCall.Opsgenie.OtherAPIKeys != nil {
+			apiKey := clonedCfg.OnCall.Opsgenie.OtherAPIKeys[v]
+
+			if apiKey != "" {
+				clonedCfg.OnCall.Opsgenie.APIKey = apiKey
+			}
+		}
+	}
+
+	if v := (*paramsOverwrite)["webhook_other_url"]; v != "" {
+		if clonedCfg.OnCall.Webhook.OtherURLs != nil {
+			webhookURL := clonedCfg.OnCall.Webhook.OtherURLs[v]
+
+			if webhookURL != "" {
+				clonedCfg.OnCall.Webhook.URL = webhookURL
+			}
+		}
+	}
+
 	return clonedCfg
 }
+
+// onCallParams are the parameters that change how an incident is paged, they name
+// the credentials to use instead of holding them
+var onCallParams = []string{
+	"oncall_providers",
+	"oncall_mode",
+	"awsim_other_response_plan",
+	"pagerduty_other_routing_key",
+	"opsgenie_other_api_key",
+	"webhook_other_url",
+}
+
+// OnCallParams returns the on-call parameters among the given ones, so the on-call config
+// of an incident can be rebuilt later with GetConfigWitParamsOverwrite
+func OnCallParams(params map[string]string) map[string]string {
+	selected := make(map[string]string)
+	for _, name := range onCallParams {
+		if v := params[name]; v != "" {
+			selected[name] = v
+		}
+	}
+	return selected
+}
+
+// expandEnv replaces ${VAR} with environment variables in the strings of a list or map
+func expandEnv(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return os.ExpandEnv(v)
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded[i] = expandEnv(item)
+		}
+		return expanded
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			expanded[k] = expandEnv(item)
+		}
+		return expanded
+	}
+	return value
+}
+
+// splitList splits a comma-separated value and drops empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Named instances of a provider are selected with ?<provider>_instance=<name> or the channels of a route.
+// When none is selected and the provider has no top-level settings, e.g. with the
+// "slack: [{name: payments, ...}]" shorthand, the first instance is used.
+// Instances without a template_path use the one of the provider. The templates of a route are
+// applied to the selected instance, so they take precedence over its template_path.
+
+// ValidateInstances rejects the <provider>_instance parameters that don't name an instance
+// of the provider, the alert would silently go to the default instance otherwise
+func (c AlertConfig) ValidateInstances(params map[string]string) error {
+	names := map[string][]string{}
+	for _, i := range c.Slack.Instances {
+		names["slack"] = append(names["slack"], i.Name)
+	}
+	for _, i := range c.Telegram.Instances {
+		names["telegram"] = append(names["telegram"], i.Name)
+	}
+	for _, i := range c.Viber.Instances {
+		names["viber"] = append(names["viber"], i.Name)
+	}
+	for _, i := range c.Email.Instances {
+		names["email"] = append(names["email"], i.Name)
+	}
+	for _, i := range c.MSTeams.Instances {
+		names["msteams"] = append(names["msteams"], i.Name)
+	}
+	for _, i := range c.Lark.Instances {
+		names["lark"] = append(names["lark"], i.Name)
+	}
+
+	for _, provider := range []string{"slack", "telegram", "viber", "email", "msteams", "lark"} {
+		name := params[provider+"_instance"]
+		if name != "" && !slices.Contains(names[provider], name) {
+			return fmt.Errorf("unknown %s instance '%s'", provider, name)
+		}
+	}
+
+	return nil
+}
+
+// validateInstances checks the instances selected by the channels of the routes and teams
+func (c *Config) validateInstances() error {
+	for _, tc := range c.Teams {
+		if err := c.Alert.ValidateInstances(tc.Channels); err != nil {
+			return fmt.Errorf("team '%s': %w", tc.ID, err)
+		}
+	}
+
+	return c.validateRouteInstances("routes", c.Routes)
+}
+
+func (c *Config) validateRouteInstances(parent string, routes []RouteConfig) error {
+	for i, rc := range routes {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("%s[%d]", parent, i)
+		}
+
+		if err := c.Alert.ValidateInstances(rc.Channels); err != nil {
+			return fmt.Errorf("route '%s': %w", name, err)
+		}
+		if err := c.validateRouteInstances(name, rc.Routes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// instanceFields points to the settings shared by the instances of a provider
+type instanceFields[T any] struct {
+	name         *string
+	enable       *bool
+	templatePath *string
+	instances    *[]T
+}
+
+type instanceConfig[T any] interface {
+	*T
+	fields() instanceFields[T]
+}
+
+// instance returns the named instance of c, or the first one when c has no credentials of its own
+func instance[T any, P instanceConfig[T]](c T, name string, hasCredentials bool) T {
+	base := P(&c).fields()
+
+	for _, i := range *base.instances {
+		if name != "" && *P(&i).fields().name == name {
+			return withInstance[T, P](c, i)
+		}
+	}
+
+	if !hasCredentials && len(*base.instances) > 0 {
+		return withInstance[T, P](c, (*base.instances)[0])
+	}
+
+	return c
+}
+
+func withInstance[T any, P instanceConfig[T]](c T, instance T) T {
+	base, selected := P(&c).fields(), P(&instance).fields()
+
+	*selected.enable = *base.enable
+	*selected.instances = nil // Selected, so Instance("") keeps the settings applied to it afterwards
+	if *selected.templatePath == "" {
+		*selected.templatePath = *base.templatePath
+	}
+	return instance
+}
+
+// Instance returns the Slack settings of the named instance, or the default ones
+func (c SlackConfig) Instance(name string) SlackConfig {
+	return instance(c, name, c.Token != "")
+}
+
+func (c *SlackConfig) fields() instanceFields[SlackConfig] {
+	return instanceFields[SlackConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
+
+// Instance returns the Telegram settings of the named instance, or the default ones
+func (c TelegramConfig) Instance(name string) TelegramConfig {
+	return instance(c, name, c.BotToken != "")
+}
+
+func (c *TelegramConfig) fields() instanceFields[TelegramConfig] {
+	return instanceFields[TelegramConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
+
+// Instance returns the Viber settings of the named instance, or the default ones
+func (c ViberConfig) Instance(name string) ViberConfig {
+	return instance(c, name, c.BotToken != "")
+}
+
+func (c *ViberConfig) fields() instanceFields[ViberConfig] {
+	return instanceFields[ViberConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
+
+// Instance returns the Email settings of the named instance, or the default ones
+func (c EmailConfig) Instance(name string) EmailConfig {
+	return instance(c, name, c.SMTPHost != "")
+}
+
+func (c *EmailConfig) fields() instanceFields[EmailConfig] {
+	return instanceFields[EmailConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
+
+// Instance returns the MS Teams settings of the named instance, or the default ones
+func (c MSTeamsConfig) Instance(name string) MSTeamsConfig {
+	return instance(c, name, c.PowerAutomateURL != "")
+}
+
+func (c *MSTeamsConfig) fields() instanceFields[MSTeamsConfig] {
+	return instanceFields[MSTeamsConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
+
+// Instance returns the Lark settings of the named instance, or the default ones
+func (c LarkConfig) Instance(name string) LarkConfig {
+	return instance(c, name, c.WebhookURL != "")
+}
+
+func (c *LarkConfig) fields() instanceFields[LarkConfig] {
+	return instanceFields[LarkConfig]{&c.Name, &c.Enable, &c.TemplatePath, &c.Instances}
+}
@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+func TestSlackInstance(t *testing.T) {
+	instances := []SlackConfig{
+		{Name: "payments", Token: "payments-token", TemplatePath: "config/slack_payments.tmpl"},
+		{Name: "search", Token: "search-token"},
+	}
+
+	tests := []struct {
+		name         string
+		config       SlackConfig
+		instance     string
+		wantToken    string
+		wantTemplate string
+	}{
+		{"default settings", SlackConfig{Enable: true, Token: "token", TemplatePath: "config/slack.tmpl", Instances: instances}, "", "token", "config/slack.tmpl"},
+		{"named instance", SlackConfig{Enable: true, Token: "token", TemplatePath: "config/slack.tmpl", Instances: instances}, "payments", "payments-token", "config/slack_payments.tmpl"},
+		{"inherited template", SlackConfig{Enable: true, Token: "token", TemplatePath: "config/slack.tmpl", Instances: instances}, "search", "search-token", "config/slack.tmpl"},
+		{"unknown instance", SlackConfig{Enable: true, Token: "token", TemplatePath: "config/slack.tmpl", Instances: instances}, "billing", "token", "config/slack.tmpl"},
+		{"first instance without top-level token", SlackConfig{Enable: true, TemplatePath: "config/slack.tmpl", Instances: instances}, "", "payments-token", "config/slack_payments.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.Instance(tt.instance)
+			if got.Token != tt.wantToken || got.TemplatePath != tt.wantTemplate {
+				t.Errorf("Instance(%q) = token %q, template %q, want %q, %q", tt.instance, got.Token, got.TemplatePath, tt.wantToken, tt.wantTemplate)
+			}
+			if !got.Enable {
+				t.Errorf("Instance(%q) is not enabled", tt.instance)
+			}
+		})
+	}
+
+	// The selected instance keeps its settings when it is selected again
+	selected := SlackConfig{Enable: true, Instances: instances}.Instance("search")
+	if got := selected.Instance(""); got.Token != "search-token" {
+		t.Errorf("Instance(\"\") of a selected instance = token %q, want %q", got.Token, "search-token")
+	}
+}
@@ -8,9 +8,31 @@ import (
 func HandleAck(c *fiber.Ctx) error {
 	incidentID := c.Params("incidentID")
 
-	if err := core.GetOnCallWorkflow().Ack(incidentID); err != nil {
+	workflow := core.GetOnCallWorkflow()
+	if workflow == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "on-call is not enabled"})
+	}
+
+	if err := workflow.Ack(incidentID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success"})
 }
+
+// GetOnCallResults returns the per-provider on-call results of an incident
+func GetOnCallResults(c *fiber.Ctx) error {
+	incidentID := c.Params("incidentID")
+
+	workflow := core.GetOnCallWorkflow()
+	if workflow == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "on-call is not enabled"})
+	}
+
+	results, err := workflow.Results(incidentID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"incident_id": incidentID, "results": results})
+}
@@ -1,15 +1,63 @@
 package controllers
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"strings"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/routing"
 	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/sources"
+	"github.com/VersusControl/versus-incident/pkg/store"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func CreateIncident(c *fiber.Ctx) error {
+	return createIncident(c, "", nil)
+}
+
+// CreateTeamIncident creates an incident with the settings of the team
+func CreateTeamIncident(c *fiber.Ctx) error {
+	teamID := c.Params("team")
+
+	if router := routing.GetRouter(); router == nil || !router.HasTeam(teamID) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Team not found"})
+	}
+
+	return createIncident(c, teamID, nil)
+}
+
+// CreateSourceIncident creates an incident from the payload of a known source, e.g. /api/sources/datadog
+func CreateSourceIncident(c *fiber.Ctx) error {
+	name := c.Params("source")
+
+	adapter := sources.Get(name)
+	if adapter == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Source not found"})
+	}
+
+	// Sources that can't set headers, e.g. Azure action groups, put the token in the URL
+	if token := config.GetConfig().Sources[name].Token; token != "" {
+		given := c.Query("token")
+		if bearer, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+			given = bearer
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+		}
+	}
+
+	return createIncident(c, "", adapter)
+}
+
+// createIncident creates an incident from the request body, adapter is nil to detect the source
+func createIncident(c *fiber.Ctx, teamID string, adapter core.SourceAdapter) error {
 	cfg := config.GetConfig()
 
 	if cfg.Alert.DebugBody {
@@ -28,11 +76,21 @@ func CreateIncident(c *fiber.Ctx) error {
 	var err error
 
 	// If query parameters exist, get the value to overwrite the default configuration
+	var params []*map[string]string
 	if len(c.Queries()) > 0 {
 		overwriteVaule := c.Queries()
-		err = services.CreateIncident("", body, &overwriteVaule)
+		delete(overwriteVaule, "token") // Authenticates source endpoints, it isn't a setting
+		params = append(params, &overwriteVaule)
+
+		if err := cfg.Alert.ValidateInstances(overwriteVaule); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if adapter != nil {
+		err = services.CreateSourceIncident(adapter, teamID, body, params...)
 	} else {
-		err = services.CreateIncident("", body)
+		err = services.CreateIncident(teamID, body, params...)
 	}
 
 	if err != nil {
@@ -41,3 +99,49 @@ func CreateIncident(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "Incident created"})
 }
+
+// ListIncidents returns the recorded incidents, most recent first.
+// Use ?suppressed=true to only list the incidents that weren't notified
+func ListIncidents(c *fiber.Ctx) error {
+	incidents := store.GetIncidentStore()
+	if incidents == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Incident store is not initialized"})
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid limit"})
+	}
+
+	list, err := incidents.List(limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	suppressed := c.QueryBool("suppressed")
+
+	result := []*m.Incident{}
+	for _, incident := range list {
+		if suppressed && incident.SuppressedReason == "" {
+			continue
+		}
+		result = append(result, incident)
+	}
+
+	return c.JSON(fiber.Map{"incidents": result})
+}
+
+// GetIncident returns a recorded incident
+func GetIncident(c *fiber.Ctx) error {
+	incidents := store.GetIncidentStore()
+	if incidents == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Incident store is not initialized"})
+	}
+
+	incident, err := incidents.Get(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(incident)
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxBatchItems     = 1000
+	maxBatchBodySize  = 32 << 20 // Once decompressed
+	batchWorkers      = 8
+	batchStatusOK     = "created"
+	batchStatusFailed = "failed"
+)
+
+var errBatchTooLarge = errors.New("batch too large")
+
+// BatchItemStatus is the result of an item of a batch, in the order of the request
+type BatchItemStatus struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CreateIncidentBatch creates an incident per item of a JSON array or of newline-delimited JSON,
+// e.g. from Fluent Bit or Vector. The body can be gzip-compressed. Items are created in parallel,
+// the response has the status of each item: 201 when all are created, 207 when some failed.
+func CreateIncidentBatch(c *fiber.Ctx) error {
+	cfg := config.GetConfig()
+
+	body, err := batchBody(c)
+	if err != nil {
+		if errors.Is(err, errBatchTooLarge) {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if cfg.Alert.DebugBody {
+		fmt.Println("Raw Request Body:", string(body))
+	}
+
+	items, err := parseBatch(body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(items) > maxBatchItems {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("%d items, the limit is %d", len(items), maxBatchItems),
+		})
+	}
+
+	// Query parameters overwrite the configuration of every item
+	var params []*map[string]string
+	if len(c.Queries()) > 0 {
+		overwrite := c.Queries()
+		delete(overwrite, "token") // Authenticates source endpoints, it isn't a setting
+		params = append(params, &overwrite)
+
+		if err := cfg.Alert.ValidateInstances(overwrite); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	statuses := make([]BatchItemStatus, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(batchWorkers, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				statuses[i] = createBatchItem(i, items[i], params)
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, s := range statuses {
+		if s.Status != batchStatusOK {
+			failed++
+		}
+	}
+
+	status := fiber.StatusCreated
+	if failed > 0 {
+		status = fiber.StatusMultiStatus
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"created": len(items) - failed,
+		"failed":  failed,
+		"items":   statuses,
+	})
+}
+
+// batchItem is an item of a batch, or the reason it can't be decoded
+type batchItem struct {
+	content map[string]interface{}
+	err     error
+}
+
+func createBatchItem(index int, item batchItem, params []*map[string]string) BatchItemStatus {
+	if item.err != nil {
+		return BatchItemStatus{Index: index, Status: batchStatusFailed, Error: item.err.Error()}
+	}
+
+	if err := services.CreateIncident("", &item.content, params...); err != nil {
+		return BatchItemStatus{Index: index, Status: batchStatusFailed, Error: err.Error()}
+	}
+
+	return BatchItemStatus{Index: index, Status: batchStatusOK}
+}
+
+// batchBody returns the request body, decompressed when it is gzip-compressed.
+// Gzip is decompressed here rather than by Fiber, to limit the size of the decompressed body.
+func batchBody(c *fiber.Ctx) ([]byte, error) {
+	if !strings.EqualFold(c.Get(fiber.HeaderContentEncoding), "gzip") {
+		return c.Body(), nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(c.BodyRaw()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxBatchBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip body: %w", err)
+	}
+	if len(data) > maxBatchBodySize {
+		return nil, fmt.Errorf("%w, the limit is %d bytes once decompressed", errBatchTooLarge, maxBatchBodySize)
+	}
+
+	return data, nil
+}
+
+// parseBatch reads a JSON array, or an object per line. An item that isn't a JSON object
+// fails on its own, the other items are still created.
+func parseBatch(body []byte) ([]batchItem, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	if body[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+
+		items := make([]batchItem, len(raw))
+		for i, r := range raw {
+			items[i] = decodeBatchItem(r)
+		}
+		return items, nil
+	}
+
+	var items []batchItem
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), maxBatchBodySize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		items = append(items, decodeBatchItem(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid NDJSON: %w", err)
+	}
+
+	// A single object on several lines, e.g. pretty-printed JSON
+	if len(items) > 1 {
+		for _, item := range items {
+			if item.err != nil {
+				if whole := decodeBatchItem(body); whole.err == nil {
+					return []batchItem{whole}, nil
+				}
+				break
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func decodeBatchItem(data []byte) batchItem {
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return batchItem{err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	if content == nil {
+		return batchItem{err: fmt.Errorf("not a JSON object")}
+	}
+	return batchItem{content: content}
+}
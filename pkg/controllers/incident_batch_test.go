@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantTitles []string // Empty for an item that fails
+		wantErr    bool
+	}{
+		{name: "array", body: `[{"title":"a"},{"title":"b"}]`, wantTitles: []string{"a", "b"}},
+		{name: "array with invalid item", body: `[{"title":"a"},"b",null]`, wantTitles: []string{"a", "", ""}},
+		{name: "NDJSON", body: "{\"title\":\"a\"}\n\n{\"title\":\"b\"}\r\n", wantTitles: []string{"a", "b"}},
+		{name: "NDJSON with invalid line", body: "{\"title\":\"a\"}\n{\"title\":\n{\"title\":\"c\"}", wantTitles: []string{"a", "", "c"}},
+		{name: "pretty-printed object", body: "{\n  \"title\": \"a\"\n}\n", wantTitles: []string{"a"}},
+		{name: "single object", body: `{"title":"a"}`, wantTitles: []string{"a"}},
+		{name: "empty body", body: " \n", wantErr: true},
+		{name: "invalid array", body: `[{"title":"a"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseBatch([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(items) != len(tt.wantTitles) {
+				t.Fatalf("parseBatch() returned %d items, want %d", len(items), len(tt.wantTitles))
+			}
+
+			for i, title := range tt.wantTitles {
+				if title == "" {
+					if items[i].err == nil {
+						t.Errorf("item %d = %v, want an error", i, items[i].content)
+					}
+					continue
+				}
+				if items[i].err != nil || items[i].content["title"] != title {
+					t.Errorf("item %d = %v, %v, want title %s", i, items[i].content, items[i].err, title)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchBody(t *testing.T) {
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(s))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name       string
+		body       []byte
+		encoding   string
+		wantStatus int
+		wantBody   string
+	}{
+		{"plain", []byte(`[{"title":"a"}]`), "", fiber.StatusOK, `[{"title":"a"}]`},
+		{"gzip", gzipped(`[{"title":"a"}]`), "gzip", fiber.StatusOK, `[{"title":"a"}]`},
+		{"invalid gzip", []byte(`[{"title":"a"}]`), "gzip", fiber.StatusBadRequest, ""},
+		{"too large once decompressed", gzipped(strings.Repeat(" ", maxBatchBodySize+1)), "gzip", fiber.StatusRequestEntityTooLarge, ""},
+	}
+
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		body, err := batchBody(c)
+		if err != nil {
+			if errors.Is(err, errBatchTooLarge) {
+				return c.SendStatus(fiber.StatusRequestEntityTooLarge)
+			}
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+		return c.Send(body)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set(fiber.HeaderContentEncoding, tt.encoding)
+			}
+
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var got bytes.Buffer
+			got.ReadFrom(resp.Body)
+			if resp.StatusCode != tt.wantStatus || (tt.wantBody != "" && got.String() != tt.wantBody) {
+				t.Errorf("batchBody() = %d %q, want %d %q", resp.StatusCode, got.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
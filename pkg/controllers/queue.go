@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/gofiber/fiber/v2"
+)
+
+var queueListeners []core.QueueListener
+
+// SetQueueListeners sets the started queue listeners for the controller
+func SetQueueListeners(listeners []core.QueueListener) {
+	queueListeners = listeners
+}
+
+// GetQueueStatus returns the progress of the listeners that report it, e.g. the lag of Kafka partitions
+func GetQueueStatus(c *fiber.Ctx) error {
+	statuses := []map[string]interface{}{}
+	for _, l := range queueListeners {
+		if r, ok := l.(core.QueueStatusReporter); ok {
+			statuses = append(statuses, r.QueueStatus())
+		}
+	}
+
+	if len(statuses) == 0 {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "disabled",
+			"message": "No queue listener reports its status",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":    "enabled",
+		"listeners": statuses,
+	})
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestRoutes shows which routes a sample payload hits, without sending anything.
+// The team can be given with ?team=
+func TestRoutes(c *fiber.Ctx) error {
+	router := routing.GetRouter()
+	if router == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Routing is not initialized"})
+	}
+
+	body := map[string]interface{}{}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	payload := routing.NewPayload(c.Query("team"), body)
+
+	return c.JSON(fiber.Map{
+		"fields": payload.Fields(),
+		"routes": router.Match(payload),
+	})
+}
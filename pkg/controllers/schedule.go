@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/schedule"
+	"github.com/gofiber/fiber/v2"
+)
+
+// scheduleResponse is the API representation of a schedule
+type scheduleResponse struct {
+	config.ScheduleConfig
+	OnCall *schedule.Shift `json:"oncall"`
+}
+
+func newScheduleResponse(s *schedule.Schedule) scheduleResponse {
+	return scheduleResponse{
+		ScheduleConfig: s.Definition(),
+		OnCall:         s.OnCallAt(time.Now()),
+	}
+}
+
+func schedulesDisabled(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"status":  "disabled",
+		"message": "On-call schedules are not enabled",
+	})
+}
+
+// ListSchedules returns all on-call schedules with who is on call now
+func ListSchedules(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	schedules := []scheduleResponse{}
+	for _, s := range store.List() {
+		schedules = append(schedules, newScheduleResponse(s))
+	}
+
+	return c.JSON(fiber.Map{"schedules": schedules})
+}
+
+// GetSchedule returns one on-call schedule
+func GetSchedule(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	s, ok := store.Get(c.Params("name"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Schedule not found"})
+	}
+
+	return c.JSON(newScheduleResponse(s))
+}
+
+// PutSchedule creates or replaces an on-call schedule
+func PutSchedule(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	var def config.ScheduleConfig
+	if err := c.BodyParser(&def); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	def.Name = c.Params("name")
+
+	s, err := store.Put(def)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newScheduleResponse(s))
+}
+
+// DeleteSchedule removes an on-call schedule created through the API
+func DeleteSchedule(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	if err := store.Delete(c.Params("name")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "Schedule deleted"})
+}
+
+// AddScheduleOverride adds an override to an on-call schedule
+func AddScheduleOverride(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	var oc config.ScheduleOverrideConfig
+	if err := c.BodyParser(&oc); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	s, err := store.AddOverride(c.Params("name"), oc)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newScheduleResponse(s))
+}
+
+// GetScheduleOnCall returns who is on call now, or at the time given in ?at=RFC3339
+func GetScheduleOnCall(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'at' time, expected RFC 3339"})
+		}
+		at = t
+	}
+
+	shift, err := store.OnCall(c.Params("name"), at)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"schedule": c.Params("name"), "at": at, "oncall": shift})
+}
+
+// GetScheduleShifts returns the final shifts between ?from and ?to (RFC 3339), defaulting to the next 14 days
+func GetScheduleShifts(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	s, ok := store.Get(c.Params("name"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Schedule not found"})
+	}
+
+	from, to, err := parseShiftRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"schedule": s.Name, "shifts": s.Shifts(from, to)})
+}
+
+// GetScheduleCalendar returns the upcoming shifts as an iCalendar feed that can be subscribed to,
+// optionally filtered to one person with ?user=. The calendar_token must be given as ?token=
+func GetScheduleCalendar(c *fiber.Ctx) error {
+	store := schedule.GetStore()
+	if store == nil {
+		return schedulesDisabled(c)
+	}
+
+	// Calendar clients can't send headers, so the token is passed in the subscription URL
+	cfg := config.GetConfig().OnCallSchedules
+	if cfg.CalendarToken == "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Calendar feed is disabled, set oncall_schedules.calendar_token"})
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(cfg.CalendarToken)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	s, ok := store.Get(c.Params("name"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Schedule not found"})
+	}
+
+	userID := c.Query("user")
+	if userID != "" {
+		if _, ok := store.User(userID); !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+	}
+
+	days := cfg.CalendarDays
+	if days <= 0 {
+		days = 30
+	}
+
+	// Keep the last week so recent shifts don't disappear from the calendar
+	now := time.Now()
+	from := now.AddDate(0, 0, -7)
+	to := now.AddDate(0, 0, days)
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, "inline; filename=\""+s.Name+".ics\"")
+
+	return c.Send(s.Calendar(from, to, userID))
+}
+
+// The longest range of shifts a request can list, a rotation of hours would otherwise make years of shifts
+const maxShiftRange = 90 * 24 * time.Hour
+
+func parseShiftRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	from := time.Now()
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, from, fiber.NewError(fiber.StatusBadRequest, "Invalid 'from' time, expected RFC 3339")
+		}
+		from = t
+	}
+
+	to := from.AddDate(0, 0, 14)
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, fiber.NewError(fiber.StatusBadRequest, "Invalid 'to' time, expected RFC 3339")
+		}
+		to = t
+	}
+
+	if to.Sub(from) > maxShiftRange {
+		return from, to, fiber.NewError(fiber.StatusBadRequest, "The range from 'from' to 'to' can't be longer than 90 days")
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/silence"
+	"github.com/gofiber/fiber/v2"
+)
+
+// silenceRequest is the body of POST /api/silences, ends_at or duration is required
+type silenceRequest struct {
+	Matchers  []string  `json:"matchers"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Duration  string    `json:"duration"` // e.g. "2h", from starts_at
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+}
+
+// silenceResponse is the API representation of a silence
+type silenceResponse struct {
+	*silence.Silence
+	State string `json:"state"`
+}
+
+// maintenanceWindowResponse is the API representation of a maintenance window
+type maintenanceWindowResponse struct {
+	*silence.MaintenanceWindow
+	Active   bool       `json:"active"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+func silencesDisabled(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"status":  "disabled",
+		"message": "Silences are not enabled",
+	})
+}
+
+// CreateSilence mutes the incidents matching the matchers until ends_at
+func CreateSilence(c *fiber.Ctx) error {
+	store := silence.GetStore()
+	if store == nil {
+		return silencesDisabled(c)
+	}
+
+	var req silenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	if req.StartsAt.IsZero() {
+		req.StartsAt = time.Now()
+	}
+
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid duration, expected e.g. 2h or 30m"})
+		}
+		req.EndsAt = req.StartsAt.Add(duration)
+	}
+
+	s := &silence.Silence{
+		Matchers:  req.Matchers,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: req.CreatedBy,
+		Comment:   req.Comment,
+	}
+
+	if err := store.Create(s); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(silenceResponse{Silence: s, State: s.State(time.Now())})
+}
+
+// ListSilences returns the silences and the maintenance windows
+func ListSilences(c *fiber.Ctx) error {
+	store := silence.GetStore()
+	if store == nil {
+		return silencesDisabled(c)
+	}
+
+	now := time.Now()
+
+	silences := []silenceResponse{}
+	for _, s := range store.List() {
+		if state := c.Query("state"); state != "" && s.State(now) != state {
+			continue
+		}
+		silences = append(silences, silenceResponse{Silence: s, State: s.State(now)})
+	}
+
+	windows := []maintenanceWindowResponse{}
+	for _, w := range store.MaintenanceWindows() {
+		resp := maintenanceWindowResponse{MaintenanceWindow: w}
+		if start, end, open := w.Current(now); open {
+			resp.Active, resp.StartsAt, resp.EndsAt = true, &start, &end
+		}
+		windows = append(windows, resp)
+	}
+
+	return c.JSON(fiber.Map{"silences": silences, "maintenance_windows": windows})
+}
+
+// ExpireSilence ends a silence now
+func ExpireSilence(c *fiber.Ctx) error {
+	store := silence.GetStore()
+	if store == nil {
+		return silencesDisabled(c)
+	}
+
+	s, err := store.Expire(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(silenceResponse{Silence: s, State: s.State(time.Now())})
+}
@@ -68,6 +68,9 @@ func SNS(c *fiber.Ctx) error {
 
 			if len(c.Queries()) > 0 {
 				overwriteVaule := c.Queries()
+				if err := cfg.Alert.ValidateInstances(overwriteVaule); err != nil {
+					return c.Status(400).SendString(err.Error())
+				}
 				err = services.CreateIncident("", content, &overwriteVaule)
 			} else {
 				err = services.CreateIncident("", content)
@@ -2,27 +2,88 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
 	"github.com/aws/aws-sdk-go-v2/service/ssmincidents"
 	"github.com/go-redis/redis/v8"
 )
 
 // OnCallProvider defines the interface for on-call notification providers
 type OnCallProvider interface {
-	TriggerOnCall(ctx context.Context, incidentID string, cfg *config.OnCallConfig) error
+	TriggerOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error
 }
 
+// OnCallResolver is implemented by providers that can close a paged incident
+// when the matching resolved alert arrives
+type OnCallResolver interface {
+	ResolveOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error
+}
+
+// OnCallAcknowledger is implemented by providers that can acknowledge a paged incident
+type OnCallAcknowledger interface {
+	AckOnCall(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig) error
+}
+
+// NamedOnCallProvider pairs a provider with the name used in the configuration
+type NamedOnCallProvider struct {
+	Name     string
+	Provider OnCallProvider
+}
+
+// OnCallResult records the outcome of one provider call for an incident
+type OnCallResult struct {
+	Provider  string    `json:"provider"`
+	Action    string    `json:"action"` // "trigger", "acknowledge" or "resolve"
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+const (
+	OnCallModeAll      = "all"      // Page every provider
+	OnCallModeFailover = "failover" // Page providers in order until one succeeds
+)
+
+// escalatedIncident is kept in Redis after escalation so a later ack can be forwarded to the providers.
+// It holds no credentials: the on-call config is rebuilt from the loaded config and the parameters.
+type escalatedIncident struct {
+	Incident  *m.Incident
+	Params    map[string]string // On-call parameters of the incident, e.g. opsgenie_other_api_key
+	Providers []string          // Providers that were paged successfully
+}
+
+// config rebuilds the on-call config the incident was paged with
+func (e *escalatedIncident) config() *config.OnCallConfig {
+	return &config.GetConfigWitParamsOverwrite(&e.Params).OnCall
+}
+
+const (
+	escalatedKeyPrefix = "escalated:"
+	escalatedKeyTTL    = 24 * time.Hour
+
+	resultsKeyPrefix = "oncall_results:"
+	resultsKeyTTL    = 24 * time.Hour
+
+	// The resolved notification of an alert is a new incident with the same fingerprint,
+	// this index finds the incident that was escalated for it
+	fingerprintKeyPrefix = "oncall_fingerprint:"
+)
+
 // Function that will be implemented in the common package to avoid circular imports
-var CreateOnCallProvider func(cfg *config.Config, awsClient *ssmincidents.Client) (OnCallProvider, error)
+var CreateOnCallProviders func(cfg *config.Config, awsClient *ssmincidents.Client) ([]NamedOnCallProvider, error)
 
-// OnCallWorkflow coordinates on-call escalation with a single provider
+// OnCallWorkflow coordinates on-call escalation with one or more providers
 type OnCallWorkflow struct {
-	provider    OnCallProvider
+	providers   []NamedOnCallProvider
 	redisClient *redis.Client
 }
 
@@ -32,63 +93,189 @@ var (
 	once           sync.Once
 )
 
-// NewOnCallWorkflow creates a new on-call workflow with the given provider
-func NewOnCallWorkflow(redisClient *redis.Client, provider OnCallProvider) *OnCallWorkflow {
+// NewOnCallWorkflow creates a new on-call workflow with the given providers
+func NewOnCallWorkflow(redisClient *redis.Client, providers ...NamedOnCallProvider) *OnCallWorkflow {
 	return &OnCallWorkflow{
-		provider:    provider,
+		providers:   providers,
 		redisClient: redisClient,
 	}
 }
 
 // InitOnCallWorkflow initializes the global singleton instance
-// This is called once from main.go with the Redis client and AWS client
+// This is called once from main.go with the Redis client and AWS client.
+// A provider that can't be created is skipped with a warning, so alerting still starts.
 func InitOnCallWorkflow(awsClient *ssmincidents.Client, redisClient *redis.Client) {
 	once.Do(func() {
 		cfg := config.GetConfig()
 
-		provider, err := CreateOnCallProvider(cfg, awsClient)
+		providers, err := CreateOnCallProviders(cfg, awsClient)
 		if err != nil {
-			log.Printf("Warning: Failed to create on-call provider: %v", err)
-			provider = nil // No provider
+			log.Printf("Warning: Failed to create on-call providers: %v", err)
 		}
 
-		onCallWorkflow = NewOnCallWorkflow(redisClient, provider)
-		log.Println("On-call workflow initialized")
+		onCallWorkflow = NewOnCallWorkflow(redisClient, providers...)
+
+		names := make([]string, 0, len(providers))
+		for _, p := range providers {
+			names = append(names, p.Name)
+		}
+		if len(names) == 0 {
+			log.Printf("Warning: On-call workflow initialized without any provider, escalations will fail")
+			return
+		}
+		log.Printf("On-call workflow initialized with providers: %s", strings.Join(names, ", "))
 	})
 }
 
-// GetOnCallWorkflow returns the global singleton instance
-// This maintains compatibility with existing code
+// GetOnCallWorkflow returns the global singleton instance, or nil when on-call isn't initialized
 func GetOnCallWorkflow() *OnCallWorkflow {
-	if onCallWorkflow == nil {
-		panic("on-call workflow not initialized - call InitOnCallWorkflow first")
-	}
 	return onCallWorkflow
 }
 
-// triggerProvider triggers the on-call provider
-func (w *OnCallWorkflow) triggerProvider(ctx context.Context, incidentID string, cfg *config.OnCallConfig) error {
-	if err := w.provider.TriggerOnCall(ctx, incidentID, cfg); err != nil {
-		return err
+// selectProviders returns the providers to use for an incident, in order.
+// A per-incident providers list (e.g. ?oncall_providers=pagerduty,opsgenie) narrows and reorders them.
+func (w *OnCallWorkflow) selectProviders(cfg *config.OnCallConfig) []NamedOnCallProvider {
+	if cfg == nil || len(cfg.Providers) == 0 {
+		return w.providers
+	}
+
+	var selected []NamedOnCallProvider
+	for _, name := range cfg.Providers {
+		for _, p := range w.providers {
+			if p.Name == name {
+				selected = append(selected, p)
+			}
+		}
+	}
+
+	return selected
+}
+
+// triggerProviders pages the providers according to the configured mode
+func (w *OnCallWorkflow) triggerProviders(ctx context.Context, incident *m.Incident, cfg *config.OnCallConfig, params map[string]string) error {
+	providers := w.selectProviders(cfg)
+	if len(providers) == 0 {
+		return fmt.Errorf("no on-call provider available")
+	}
+
+	var (
+		results   []OnCallResult
+		succeeded []string
+		errs      []error
+	)
+
+	for _, p := range providers {
+		err := p.Provider.TriggerOnCall(ctx, incident, cfg)
+		results = append(results, newOnCallResult(p.Name, "trigger", err))
+
+		if err != nil {
+			log.Printf("On-call provider %s failed for incident %s: %v", p.Name, incident.ID, err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+
+		succeeded = append(succeeded, p.Name)
+
+		// In failover mode the first provider that succeeds is enough
+		if cfg.Mode == OnCallModeFailover {
+			break
+		}
+	}
+
+	w.storeResults(ctx, incident.ID, results)
+
+	if len(succeeded) > 0 {
+		w.storeEscalated(ctx, incident, params, succeeded)
+	}
+
+	// Failover only fails when every provider failed, "all" fails when any provider failed
+	if cfg.Mode == OnCallModeFailover && len(succeeded) > 0 {
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// storeEscalated remembers the escalation so a later ack can be forwarded to the providers
+func (w *OnCallWorkflow) storeEscalated(ctx context.Context, incident *m.Incident, params map[string]string, providers []string) {
+	if w.redisClient == nil {
+		return
+	}
+
+	data, err := json.Marshal(escalatedIncident{Incident: incident, Params: config.OnCallParams(params), Providers: providers})
+	if err != nil {
+		log.Printf("Failed to marshal escalated incident %s: %v", incident.ID, err)
+		return
+	}
+
+	if err := w.redisClient.Set(ctx, escalatedKeyPrefix+incident.ID, data, escalatedKeyTTL).Err(); err != nil {
+		log.Printf("Failed to store escalated incident %s in Redis: %v", incident.ID, err)
 	}
-	return nil
 }
 
-// Start initiates the on-call workflow for an incident
-func (w *OnCallWorkflow) Start(incidentID string, oc config.OnCallConfig) error {
+// storeResults appends the per-provider results of an incident in Redis
+func (w *OnCallWorkflow) storeResults(ctx context.Context, incidentID string, results []OnCallResult) {
+	if w.redisClient == nil || len(results) == 0 {
+		return
+	}
+
+	key := resultsKeyPrefix + incidentID
+	for _, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			continue
+		}
+
+		if err := w.redisClient.RPush(ctx, key, data).Err(); err != nil {
+			log.Printf("Failed to store on-call results for incident %s: %v", incidentID, err)
+			return
+		}
+	}
+
+	w.redisClient.Expire(ctx, key, resultsKeyTTL)
+}
+
+// Results returns the per-provider results recorded for an incident
+func (w *OnCallWorkflow) Results(incidentID string) ([]OnCallResult, error) {
+	if w == nil || w.redisClient == nil {
+		return nil, fmt.Errorf("the on-call workflow hasn't been properly initialized")
+	}
+
+	items, err := w.redisClient.LRange(context.Background(), resultsKeyPrefix+incidentID, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-call results for incident %s: %v", incidentID, err)
+	}
+
+	results := make([]OnCallResult, 0, len(items))
+	for _, item := range items {
+		var result OnCallResult
+		if err := json.Unmarshal([]byte(item), &result); err == nil {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// Start initiates the on-call workflow for an incident. The parameters are the ones the on-call config
+// was built with, they select the same credentials when the incident is acknowledged or resolved.
+func (w *OnCallWorkflow) Start(incident *m.Incident, oc config.OnCallConfig, params map[string]string) error {
 	if w == nil || w.redisClient == nil {
 		return fmt.Errorf("the on-call workflow hasn't been properly initialized")
 	}
 
-	if w.provider == nil {
+	if len(w.selectProviders(&oc)) == 0 {
 		return fmt.Errorf("no on-call provider available")
 	}
 
 	ctx := context.Background()
+	incidentID := incident.ID
+
+	w.indexFingerprint(ctx, incident, time.Duration(oc.WaitMinutes)*time.Minute+escalatedKeyTTL)
 
 	// If WaitMinutes is 0, trigger immediately
 	if oc.WaitMinutes == 0 {
-		return w.triggerProvider(ctx, incidentID, &oc)
+		return w.triggerProviders(ctx, incident, &oc, params)
 	}
 
 	// Store incident in Redis with expiration time
@@ -113,7 +300,7 @@ func (w *OnCallWorkflow) Start(incidentID string, oc config.OnCallConfig) error
 
 		if exists == 1 {
 			// If still pending, trigger on-call
-			if err := w.triggerProvider(ctx, incidentID, &oc); err != nil {
+			if err := w.triggerProviders(ctx, incident, &oc, params); err != nil {
 				log.Printf("Failed to trigger provider: %v", err)
 			}
 
@@ -127,6 +314,111 @@ func (w *OnCallWorkflow) Start(incidentID string, oc config.OnCallConfig) error
 	return nil
 }
 
+// Cancel stops the pending escalation of an incident, without acknowledging it on the providers
+func (w *OnCallWorkflow) Cancel(incidentID string) error {
+	if w == nil || w.redisClient == nil {
+		return nil
+	}
+
+	if err := w.redisClient.Del(context.Background(), incidentID).Err(); err != nil {
+		return fmt.Errorf("failed to cancel the escalation of incident %s: %v", incidentID, err)
+	}
+
+	return nil
+}
+
+// indexFingerprint remembers which incident was started for the fingerprint of the alert
+func (w *OnCallWorkflow) indexFingerprint(ctx context.Context, incident *m.Incident, ttl time.Duration) {
+	if incident.Fingerprint == "" {
+		return
+	}
+
+	if err := w.redisClient.Set(ctx, fingerprintKeyPrefix+incident.Fingerprint, incident.ID, ttl).Err(); err != nil {
+		log.Printf("Failed to index the fingerprint of incident %s in Redis: %v", incident.ID, err)
+	}
+}
+
+// escalatedID returns the ID of the incident started for the alert, the resolved notification
+// has its own ID. Incidents without an indexed fingerprint keep their ID, e.g. auto-resolved ones.
+func (w *OnCallWorkflow) escalatedID(ctx context.Context, incident *m.Incident) string {
+	if incident.Fingerprint == "" {
+		return incident.ID
+	}
+
+	id, err := w.redisClient.Get(ctx, fingerprintKeyPrefix+incident.Fingerprint).Result()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Failed to read the incident of fingerprint %s from Redis: %v", incident.Fingerprint, err)
+		}
+		return incident.ID
+	}
+
+	return id
+}
+
+// Resolve cancels the pending escalation of the alert and closes the incident on the providers
+// that were paged for it and support it. Incidents that were never escalated have nothing to close.
+// Failures are logged and recorded in the results, a provider that can't resolve mustn't fail the resolved alert.
+func (w *OnCallWorkflow) Resolve(incident *m.Incident) {
+	if w == nil || w.redisClient == nil {
+		return
+	}
+
+	ctx := context.Background()
+
+	// Close the incident that was escalated, not the resolved notification
+	resolved := *incident
+	resolved.ID = w.escalatedID(ctx, incident)
+
+	if err := w.Cancel(resolved.ID); err != nil {
+		log.Printf("Failed to cancel the escalation of resolved incident %s: %v", resolved.ID, err)
+	}
+
+	if incident.Fingerprint != "" {
+		if err := w.redisClient.Del(ctx, fingerprintKeyPrefix+incident.Fingerprint).Err(); err != nil {
+			log.Printf("Failed to delete the fingerprint of incident %s from Redis: %v", resolved.ID, err)
+		}
+	}
+
+	data, err := w.redisClient.Get(ctx, escalatedKeyPrefix+resolved.ID).Bytes()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read escalated incident %s from Redis: %v", resolved.ID, err)
+		return
+	}
+
+	var escalated escalatedIncident
+	if err := json.Unmarshal(data, &escalated); err != nil {
+		log.Printf("Failed to read escalated incident %s: %v", resolved.ID, err)
+		return
+	}
+
+	var results []OnCallResult
+
+	cfg := escalated.config()
+	for _, p := range w.providers {
+		resolver, ok := p.Provider.(OnCallResolver)
+		if !ok || !slices.Contains(escalated.Providers, p.Name) {
+			continue
+		}
+
+		err := resolver.ResolveOnCall(ctx, &resolved, cfg)
+		results = append(results, newOnCallResult(p.Name, "resolve", err))
+		if err != nil {
+			log.Printf("On-call provider %s failed to resolve incident %s: %v", p.Name, resolved.ID, err)
+		}
+	}
+
+	w.storeResults(ctx, resolved.ID, results)
+
+	// Nothing is left to acknowledge once the incident is resolved
+	if err := w.redisClient.Del(ctx, escalatedKeyPrefix+resolved.ID).Err(); err != nil {
+		log.Printf("Failed to delete escalated incident %s from Redis: %v", resolved.ID, err)
+	}
+}
+
 // Ack acknowledges an incident to prevent escalation
 func (w *OnCallWorkflow) Ack(incidentID string) error {
 	if w == nil || w.redisClient == nil {
@@ -144,5 +436,59 @@ func (w *OnCallWorkflow) Ack(incidentID string) error {
 		return nil
 	}
 
+	// Already escalated, forward the acknowledgment to the providers that were paged
+	data, err := w.redisClient.Get(ctx, escalatedKeyPrefix+incidentID).Bytes()
+	if err == nil {
+		var escalated escalatedIncident
+		if err := json.Unmarshal(data, &escalated); err != nil {
+			return fmt.Errorf("failed to read escalated incident %s: %v", incidentID, err)
+		}
+
+		var (
+			results []OnCallResult
+			errs    []error
+		)
+
+		cfg := escalated.config()
+		for _, p := range w.providers {
+			acknowledger, ok := p.Provider.(OnCallAcknowledger)
+			if !ok || !slices.Contains(escalated.Providers, p.Name) {
+				continue
+			}
+
+			err := acknowledger.AckOnCall(ctx, escalated.Incident, cfg)
+			results = append(results, newOnCallResult(p.Name, "acknowledge", err))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			}
+		}
+
+		w.storeResults(ctx, incidentID, results)
+
+		if err := errors.Join(errs...); err != nil {
+			return fmt.Errorf("failed to acknowledge incident %s: %w", incidentID, err)
+		}
+
+		if err := w.redisClient.Del(ctx, escalatedKeyPrefix+incidentID).Err(); err != nil {
+			log.Printf("Failed to delete escalated incident %s from Redis: %v", incidentID, err)
+		}
+		return nil
+	}
+
 	return fmt.Errorf("incident does not exist or was already acknowledged")
 }
+
+func newOnCallResult(provider, action string, err error) OnCallResult {
+	result := OnCallResult{
+		Provider:  provider,
+		Action:    action,
+		Success:   err == nil,
+		Timestamp: time.Now(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -3,3 +3,13 @@ package core
 type QueueListener interface {
 	StartListening(handler func(content *map[string]interface{}) error) error
 }
+
+// TeamQueueListener is a listener that knows the team of its messages, e.g. from the recipient of an email
+type TeamQueueListener interface {
+	StartTeamListening(handler func(teamID string, content *map[string]interface{}) error) error
+}
+
+// QueueStatusReporter is a listener that reports its progress, e.g. the lag of a Kafka consumer group
+type QueueStatusReporter interface {
+	QueueStatus() map[string]interface{}
+}
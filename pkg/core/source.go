@@ -0,0 +1,21 @@
+package core
+
+import (
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// SourceAdapter normalizes the payloads of an alert source
+type SourceAdapter interface {
+	// Name of the source, as matched by the "source" field of routes
+	Name() string
+	// Detect reports whether the payload comes from this source
+	Detect(content map[string]interface{}) bool
+	// Normalize extracts the normalized fields from the payload
+	Normalize(content map[string]interface{}) m.Normalized
+}
+
+// Fingerprinter is implemented by the adapters of sources that identify their alerts, the
+// fingerprint links the firing and the resolved notifications of an alert
+type Fingerprinter interface {
+	Fingerprint(content map[string]interface{}) string
+}
@@ -0,0 +1,161 @@
+package grouping
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Sender delivers a notification to the providers and channels of a route,
+// update is false for the first notification of the group
+type Sender func(incident *m.Incident, update bool) error
+
+// Grouper merges the incidents of the same group into one notification, the incidents
+// arriving after it are posted as updates in the thread of that notification
+type Grouper struct {
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+type group struct {
+	key      string
+	policy   *Policy
+	first    *m.Incident
+	pending  []*m.Incident // Arrived since the last notification
+	count    int
+	threads  map[string]string // Provider -> message of the group notification
+	open     Sender            // Sender of the first incident, for the group notification
+	send     Sender            // Sender of the latest incident, for the updates
+	notified bool
+}
+
+// Global instance for singleton access
+var (
+	grouper     *Grouper
+	grouperOnce sync.Once
+)
+
+// NewGrouper creates a grouper without any group
+func NewGrouper() *Grouper {
+	return &Grouper{groups: make(map[string]*group)}
+}
+
+// InitGrouper initializes the global singleton instance
+func InitGrouper() {
+	grouperOnce.Do(func() {
+		grouper = NewGrouper()
+	})
+}
+
+// GetGrouper returns the global singleton instance, or nil when it isn't initialized
+func GetGrouper() *Grouper {
+	return grouper
+}
+
+// Add puts the incident in its group and reports whether it opened the group.
+// A new group is notified after group_wait with the incidents received meanwhile, then
+// the incidents received during each group_interval are posted as one thread update.
+// The group is closed after a group_interval without incidents.
+func (g *Grouper) Add(key string, policy *Policy, incident *m.Incident, send Sender) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gr, ok := g.groups[key]
+	if ok {
+		gr.count++
+		gr.send = send
+		gr.pending = append(gr.pending, incident)
+		return false
+	}
+
+	gr = &group{
+		key:     key,
+		policy:  policy,
+		first:   incident,
+		count:   1,
+		threads: make(map[string]string),
+		open:    send,
+		send:    send,
+	}
+	g.groups[key] = gr
+
+	go g.run(gr)
+
+	return true
+}
+
+func (g *Grouper) run(gr *group) {
+	time.Sleep(gr.policy.wait)
+
+	ticker := time.NewTicker(gr.policy.interval)
+	defer ticker.Stop()
+
+	for {
+		if closed := g.flush(gr); closed {
+			return
+		}
+		<-ticker.C
+	}
+}
+
+// flush sends the group notification, or a thread update with the pending incidents,
+// and reports whether the group is closed
+func (g *Grouper) flush(gr *group) bool {
+	g.mu.Lock()
+
+	var (
+		incident *m.Incident
+		included []*m.Incident // Incidents the notification stands for
+	)
+	update := gr.notified
+	switch {
+	case !gr.notified:
+		incident = gr.first
+		included = append([]*m.Incident{gr.first}, gr.pending...)
+		gr.notified = true
+	case len(gr.pending) > 0:
+		incident = gr.pending[len(gr.pending)-1] // The latest incident stands for the update
+		included = gr.pending
+	default:
+		delete(g.groups, gr.key)
+		g.mu.Unlock()
+		return true
+	}
+
+	notification := *incident
+	notification.Threads = gr.threads
+
+	incidents := make([]m.Normalized, len(included))
+	for i, inc := range included {
+		incidents[i] = inc.Normalized
+	}
+
+	// Expose the group to templates, e.g. {{ .GroupCount }} or {{ range .GroupIncidents }}{{ .Title }}{{ end }}.
+	// GroupNew counts the incidents received after the first one during group_wait, or since the last update.
+	content := make(map[string]interface{}, len(*incident.Content)+5)
+	for k, v := range *incident.Content {
+		content[k] = v
+	}
+	content["GroupKey"] = gr.key
+	content["GroupCount"] = gr.count
+	content["GroupUpdate"] = update
+	content["GroupNew"] = len(gr.pending)
+	content["GroupIncidents"] = incidents
+	notification.Content = &content
+
+	gr.pending = nil
+	send := gr.send
+	if !update {
+		send = gr.open
+	}
+	g.mu.Unlock()
+
+	// Sending is done outside the lock, only this goroutine uses the threads of the group
+	if err := send(&notification, update); err != nil {
+		log.Printf("Failed to send the notification of group %s: %v", gr.key, err)
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+package grouping
+
+import (
+	"testing"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+type sent struct {
+	sender   string
+	incident *m.Incident
+	update   bool
+}
+
+func newIncident(id string) *m.Incident {
+	content := map[string]interface{}{"id": id}
+	return &m.Incident{ID: id, Content: &content, Normalized: m.Normalized{Title: id}}
+}
+
+func TestGrouperFlush(t *testing.T) {
+	// A long group_wait keeps the background flush away, the test flushes the group itself
+	policy, err := ParsePolicy([]string{"alertname"}, "1h", "1h", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notifications []sent
+	sender := func(name string) Sender {
+		return func(incident *m.Incident, update bool) error {
+			notifications = append(notifications, sent{name, incident, update})
+			return nil
+		}
+	}
+
+	g := NewGrouper()
+	if !g.Add("cpu", policy, newIncident("first"), sender("first")) {
+		t.Fatal("the first incident didn't open the group")
+	}
+	if g.Add("cpu", policy, newIncident("second"), sender("second")) {
+		t.Fatal("the second incident opened another group")
+	}
+	gr := g.groups["cpu"]
+
+	tests := []struct {
+		name       string
+		add        []string
+		wantClosed bool
+		wantSender string
+		wantID     string
+		wantUpdate bool
+		wantCount  int
+		wantNew    int
+		wantTitles []string
+	}{
+		{name: "group notification", wantSender: "first", wantID: "first", wantCount: 2, wantNew: 1, wantTitles: []string{"first", "second"}},
+		{name: "update", add: []string{"third", "fourth"}, wantSender: "fourth", wantID: "fourth", wantUpdate: true, wantCount: 4, wantNew: 2, wantTitles: []string{"third", "fourth"}},
+		{name: "closed without incidents", wantClosed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, id := range tt.add {
+				g.Add("cpu", policy, newIncident(id), sender(id))
+			}
+
+			notifications = nil
+			closed := g.flush(gr)
+			if closed != tt.wantClosed {
+				t.Fatalf("flush() closed = %v, want %v", closed, tt.wantClosed)
+			}
+			if closed {
+				if _, ok := g.groups["cpu"]; ok || len(notifications) > 0 {
+					t.Errorf("closed group is still open or notified")
+				}
+				return
+			}
+
+			if len(notifications) != 1 {
+				t.Fatalf("got %d notifications, want 1", len(notifications))
+			}
+			n := notifications[0]
+			content := *n.incident.Content
+
+			if n.sender != tt.wantSender || n.incident.ID != tt.wantID || n.update != tt.wantUpdate {
+				t.Errorf("sent %s with sender %s, update %v, want %s with sender %s, update %v", n.incident.ID, n.sender, n.update, tt.wantID, tt.wantSender, tt.wantUpdate)
+			}
+			if content["GroupCount"] != tt.wantCount || content["GroupNew"] != tt.wantNew || content["GroupUpdate"] != tt.wantUpdate {
+				t.Errorf("GroupCount = %v, GroupNew = %v, GroupUpdate = %v, want %d, %d, %v", content["GroupCount"], content["GroupNew"], content["GroupUpdate"], tt.wantCount, tt.wantNew, tt.wantUpdate)
+			}
+
+			incidents := content["GroupIncidents"].([]m.Normalized)
+			if len(incidents) != len(tt.wantTitles) {
+				t.Fatalf("GroupIncidents has %d incidents, want %d", len(incidents), len(tt.wantTitles))
+			}
+			for i, title := range tt.wantTitles {
+				if incidents[i].Title != title {
+					t.Errorf("GroupIncidents[%d] = %s, want %s", i, incidents[i].Title, title)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	parent := &Policy{By: []string{"alertname"}, Wait: "10s", Interval: "1m"}
+
+	tests := []struct {
+		name         string
+		by           []string
+		wait         string
+		interval     string
+		parent       *Policy
+		wantNil      bool
+		wantErr      bool
+		wantWait     string
+		wantInterval string
+	}{
+		{name: "no grouping", wantNil: true},
+		{name: "defaults", by: []string{"alertname"}, wantWait: defaultWait, wantInterval: defaultInterval},
+		{name: "inherited", parent: parent, wantWait: "10s", wantInterval: "1m"},
+		{name: "overridden wait", wait: "0s", parent: parent, wantWait: "0s", wantInterval: "1m"},
+		{name: "wait without group_by", wait: "10s", wantErr: true},
+		{name: "invalid wait", by: []string{"alertname"}, wait: "soon", wantErr: true},
+		{name: "zero interval", by: []string{"alertname"}, interval: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy(tt.by, tt.wait, tt.interval, tt.parent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (p == nil) != tt.wantNil {
+				t.Fatalf("ParsePolicy() = %v, wantNil %v", p, tt.wantNil)
+			}
+			if p != nil && (p.Wait != tt.wantWait || p.Interval != tt.wantInterval) {
+				t.Errorf("ParsePolicy() = wait %s, interval %s, want %s, %s", p.Wait, p.Interval, tt.wantWait, tt.wantInterval)
+			}
+		})
+	}
+}
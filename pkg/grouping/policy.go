@@ -0,0 +1,67 @@
+package grouping
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy is how the incidents of a route are grouped
+type Policy struct {
+	By       []string `json:"group_by"`
+	Wait     string   `json:"group_wait"`
+	Interval string   `json:"group_interval"`
+
+	wait     time.Duration
+	interval time.Duration
+}
+
+// Defaults of Alertmanager
+const (
+	defaultWait     = "30s"
+	defaultInterval = "5m"
+)
+
+// ParsePolicy validates the grouping settings of a route, the settings it doesn't set are
+// inherited from the parent policy. It returns nil when the route doesn't group incidents.
+func ParsePolicy(by []string, wait, interval string, parent *Policy) (*Policy, error) {
+	if parent != nil {
+		if len(by) == 0 {
+			by = parent.By
+		}
+		if wait == "" {
+			wait = parent.Wait
+		}
+		if interval == "" {
+			interval = parent.Interval
+		}
+	}
+
+	if len(by) == 0 {
+		if wait != "" || interval != "" {
+			return nil, fmt.Errorf("group_wait and group_interval require group_by")
+		}
+		return nil, nil
+	}
+
+	p := &Policy{By: by, Wait: wait, Interval: interval}
+	if p.Wait == "" {
+		p.Wait = defaultWait
+	}
+	if p.Interval == "" {
+		p.Interval = defaultInterval
+	}
+
+	w, err := time.ParseDuration(p.Wait)
+	if err != nil || w < 0 {
+		return nil, fmt.Errorf("invalid group_wait '%s'", p.Wait)
+	}
+	p.wait = w
+
+	i, err := time.ParseDuration(p.Interval)
+	if err != nil || i <= 0 {
+		return nil, fmt.Errorf("invalid group_interval '%s'", p.Interval)
+	}
+	p.interval = i
+
+	return p, nil
+}
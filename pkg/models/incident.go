@@ -1,19 +1,49 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Incident struct {
-	ID       string                  `json:"id"`
-	TeamID   string                  `json:"team_id"`
-	Content  *map[string]interface{} `json:"content"`
-	Resolved bool
+	ID               string                  `json:"id"`
+	TeamID           string                  `json:"team_id"`
+	Fingerprint      string                  `json:"fingerprint"` // Stable key shared by the firing and resolved notifications of the same alert
+	Content          *map[string]interface{} `json:"content"`
+	Resolved         bool
+	CreatedAt        time.Time  `json:"created_at"`
+	SuppressedReason string     `json:"suppressed_reason,omitempty"` // Why the incident wasn't notified, e.g. a silence
+	AutoResolveAt    *time.Time `json:"auto_resolve_at,omitempty"`   // Resolved then, unless a new notification of the alert arrives
+
+	// Provider -> message of the group notification, providers that support threads reply
+	// to it and record the message they post when it is the first of the group
+	Threads map[string]string `json:"-"`
+
+	Normalized
+}
+
+// Normalized are the fields of an alert whatever its source, the source adapters extract
+// them from the payload and templates read them from .Incident
+type Normalized struct {
+	Source      string            `json:"source,omitempty"`   // Name of the adapter, e.g. alertmanager
+	Severity    string            `json:"severity,omitempty"` // CRITICAL, ERROR, WARNING or INFO
+	Status      string            `json:"status,omitempty"`   // FIRING or RESOLVED
+	Title       string            `json:"title,omitempty"`
+	Resource    string            `json:"resource,omitempty"`
+	Description string            `json:"description,omitempty"`
+	StartsAt    *time.Time        `json:"starts_at,omitempty"`
+	Links       map[string]string `json:"links,omitempty"` // e.g. runbook, dashboard, source, image
+	Labels      map[string]string `json:"labels,omitempty"`
+	Values      map[string]string `json:"values,omitempty"` // Values that fired the alert, e.g. the expressions of a Grafana rule
 }
 
 func NewIncident(teamID string, content *map[string]interface{}, resolved bool) *Incident {
 	return &Incident{
-		ID:       uuid.NewString(), // Generate a new UUID as a string
-		TeamID:   teamID,
-		Content:  content,
-		Resolved: resolved,
+		ID:        uuid.NewString(), // Generate a new UUID as a string
+		TeamID:    teamID,
+		Content:   content,
+		Resolved:  resolved,
+		CreatedAt: time.Now(),
 	}
 }
@@ -0,0 +1,185 @@
+package resolve
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+)
+
+// Built-in rules for the common alert sources, in the order they are checked.
+// Only the default rule applies to every payload, the others are scoped to their source.
+var presetNames = []string{"default", "cloudwatch", "sentry", "grafana", "datadog", "azure", "email"}
+
+var presets = map[string][]config.ResolveRuleConfig{
+	"default": {
+		{Fields: []string{"status", "state", "alertState"}, Values: []string{"resolved"}},
+	},
+	"cloudwatch": {
+		{Fields: []string{"NewStateValue"}, Values: []string{"OK"}, Matchers: []string{`source="cloudwatch"`}},
+	},
+	"sentry": {
+		{Fields: []string{"action", "data.issue.status"}, Values: []string{"resolved"}, Matchers: []string{`source="sentry"`}},
+	},
+	"grafana": {
+		{Fields: []string{"state", "status"}, Values: []string{"ok", "resolved"}, Matchers: []string{`source="grafana"`}}, // Legacy and unified alerting
+	},
+	"datadog": {
+		{Fields: []string{"alert_transition", "transition"}, Values: []string{"Recovered"}, Matchers: []string{`source="datadog"`}},
+	},
+	"azure": {
+		{Fields: []string{"data.essentials.monitorCondition", "data.status"}, Values: []string{"Resolved", "Deactivated"}, Matchers: []string{`source="azure"`}},
+	},
+	"email": {
+		{Fields: []string{"labels.status"}, Values: []string{"resolved", "ok", "recovered", "success"}, Matchers: []string{`source="email"`}}, // Status extracted by the SMTP listener
+	},
+}
+
+// Rule marks a payload as resolved when one of its fields has one of the values
+type Rule struct {
+	Name     string
+	Fields   []string
+	Values   []string
+	Matchers []*routing.Matcher
+}
+
+// Resolver decides whether a payload is the resolved notification of an alert
+type Resolver struct {
+	rules []*Rule
+}
+
+// Global instance for singleton access
+var (
+	resolver     *Resolver
+	resolverOnce sync.Once
+
+	// Used until the resolver is initialized from the config
+	builtin, _ = NewResolver(config.ResolveRulesConfig{})
+)
+
+// NewResolver creates a resolver from the configured presets and rules.
+// All the presets are used when none is configured.
+func NewResolver(cfg config.ResolveRulesConfig) (*Resolver, error) {
+	r := &Resolver{}
+
+	names := cfg.Presets
+	if len(names) == 0 {
+		names = presetNames
+	}
+
+	for _, name := range names {
+		rules, ok := presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown resolve preset '%s', expected one of %s", name, strings.Join(presetNames, ", "))
+		}
+
+		for _, rc := range rules {
+			rc.Name = name
+			if err := r.add(rc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, rc := range cfg.Rules {
+		if rc.Name == "" {
+			rc.Name = fmt.Sprintf("%d", i)
+		}
+		if err := r.add(rc); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// InitResolver initializes the global singleton instance
+func InitResolver(cfg config.ResolveRulesConfig) error {
+	var err error
+
+	resolverOnce.Do(func() {
+		resolver, err = NewResolver(cfg)
+		if err == nil {
+			log.Printf("Resolve rules initialized with %d rules", len(resolver.rules))
+		}
+	})
+
+	return err
+}
+
+// GetResolver returns the global singleton instance, or the built-in presets when it isn't initialized
+func GetResolver() *Resolver {
+	if resolver == nil {
+		return builtin
+	}
+	return resolver
+}
+
+func (r *Resolver) add(rc config.ResolveRuleConfig) error {
+	if len(rc.Fields) == 0 || len(rc.Values) == 0 {
+		return fmt.Errorf("resolve rule '%s': fields and values are required", rc.Name)
+	}
+
+	matchers, err := routing.ParseMatchers(rc.Matchers)
+	if err != nil {
+		return fmt.Errorf("resolve rule '%s': %w", rc.Name, err)
+	}
+
+	r.rules = append(r.rules, &Rule{
+		Name:     rc.Name,
+		Fields:   rc.Fields,
+		Values:   rc.Values,
+		Matchers: matchers,
+	})
+
+	return nil
+}
+
+// Resolved reports whether one of the rules matches the payload
+func (r *Resolver) Resolved(p *routing.Payload) bool {
+	for _, rule := range r.rules {
+		if rule.matches(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *Rule) matches(p *routing.Payload) bool {
+	if !routing.MatchAll(rule.Matchers, p) {
+		return false
+	}
+
+	for _, field := range rule.Fields {
+		value := p.Field(field)
+		if value == "" {
+			continue
+		}
+
+		for _, v := range rule.Values {
+			if strings.EqualFold(value, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Fields returns the top-level fields the rules read, they change between the
+// firing and the resolved notification of an alert
+func (r *Resolver) Fields() []string {
+	var fields []string
+	for _, rule := range r.rules {
+		for _, field := range rule.Fields {
+			if !strings.Contains(field, ".") && !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,84 @@
+package resolve
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+)
+
+func TestResolved(t *testing.T) {
+	all, err := NewResolver(config.ResolveRulesConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := NewResolver(config.ResolveRulesConfig{
+		Presets: []string{"default"},
+		Rules: []config.ResolveRuleConfig{
+			{Fields: []string{"event.phase"}, Values: []string{"closed"}, Matchers: []string{`source="nagios"`}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resolver *Resolver
+		content  map[string]interface{}
+		want     bool
+	}{
+		{"default status", all, map[string]interface{}{"status": "RESOLVED"}, true},
+		{"default firing", all, map[string]interface{}{"status": "firing"}, false},
+		{"cloudwatch", all, map[string]interface{}{"source": "cloudwatch", "NewStateValue": "OK"}, true},
+		{"cloudwatch value of another source", all, map[string]interface{}{"source": "generic", "NewStateValue": "OK"}, false},
+		{"sentry nested field", all, map[string]interface{}{"source": "sentry", "data": map[string]interface{}{"issue": map[string]interface{}{"status": "resolved"}}}, true},
+		{"grafana legacy", all, map[string]interface{}{"source": "grafana", "state": "ok"}, true},
+		{"datadog", all, map[string]interface{}{"source": "datadog", "alert_transition": "Recovered"}, true},
+		{"datadog triggered", all, map[string]interface{}{"source": "datadog", "alert_transition": "Triggered"}, false},
+		{"azure", all, map[string]interface{}{"source": "azure", "data": map[string]interface{}{"essentials": map[string]interface{}{"monitorCondition": "Resolved"}}}, true},
+		{"email", all, map[string]interface{}{"source": "email", "labels": map[string]interface{}{"status": "Recovered"}}, true},
+		{"custom rule", custom, map[string]interface{}{"source": "nagios", "event": map[string]interface{}{"phase": "closed"}}, true},
+		{"preset not selected", custom, map[string]interface{}{"source": "datadog", "alert_transition": "Recovered"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resolver.Resolved(routing.NewPayload("", tt.content)); got != tt.want {
+				t.Errorf("Resolved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolverErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ResolveRulesConfig
+	}{
+		{"unknown preset", config.ResolveRulesConfig{Presets: []string{"nagios"}}},
+		{"rule without values", config.ResolveRulesConfig{Rules: []config.ResolveRuleConfig{{Fields: []string{"status"}}}}},
+		{"rule without fields", config.ResolveRulesConfig{Rules: []config.ResolveRuleConfig{{Values: []string{"ok"}}}}},
+		{"invalid matcher", config.ResolveRulesConfig{Rules: []config.ResolveRuleConfig{{Fields: []string{"status"}, Values: []string{"ok"}, Matchers: []string{"source"}}}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewResolver(tt.cfg); err == nil {
+			t.Errorf("NewResolver() with %s succeeded", tt.name)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	r, err := NewResolver(config.ResolveRulesConfig{Presets: []string{"default", "grafana", "sentry"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nested fields aren't top-level fields
+	want := []string{"status", "state", "alertState", "action"}
+	if got := r.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+}
@@ -15,9 +15,41 @@ func SetupRoutes(app *fiber.App) {
 
 	incidents := api.Group("/incidents")
 	incidents.Post("/", controllers.CreateIncident)
+	incidents.Post("/batch", controllers.CreateIncidentBatch) // JSON array or NDJSON, e.g. from Fluent Bit or Vector
+	incidents.Get("/", controllers.ListIncidents)
+	incidents.Get("/:id", controllers.GetIncident)
+
+	api.Post("/teams/:team/incidents", controllers.CreateTeamIncident)
+
+	// Payloads of known sources, e.g. /api/sources/datadog
+	api.Post("/sources/:source", controllers.CreateSourceIncident)
 
 	api.Get("/ack/:incidentID", controllers.HandleAck)
+	api.Get("/oncall/:incidentID/results", controllers.GetOnCallResults)
+
+	// On-call schedules
+	schedules := api.Group("/schedules")
+	schedules.Get("/", controllers.ListSchedules)
+	schedules.Get("/:name", controllers.GetSchedule)
+	schedules.Put("/:name", controllers.PutSchedule)
+	schedules.Delete("/:name", controllers.DeleteSchedule)
+	schedules.Get("/:name/oncall", controllers.GetScheduleOnCall)
+	schedules.Get("/:name/shifts", controllers.GetScheduleShifts)
+	schedules.Get("/:name/calendar.ics", controllers.GetScheduleCalendar)
+	schedules.Post("/:name/overrides", controllers.AddScheduleOverride)
+
+	// Silences and maintenance windows
+	silences := api.Group("/silences")
+	silences.Post("/", controllers.CreateSilence)
+	silences.Get("/", controllers.ListSilences)
+	silences.Delete("/:id", controllers.ExpireSilence)
+
+	// Routing
+	api.Post("/routes/test", controllers.TestRoutes)
 
 	// Scheduler status endpoint
 	api.Get("/scheduler/status", controllers.GetSchedulerStatus)
+
+	// Progress of the queue listeners, e.g. the lag of Kafka partitions
+	api.Get("/queue/status", controllers.GetQueueStatus)
 }
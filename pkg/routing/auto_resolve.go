@@ -0,0 +1,34 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+)
+
+// AutoResolve resolves the firing incidents of a route that get no new notification for a while,
+// for sources that never send a resolved notification
+type AutoResolve struct {
+	After  string `json:"after"`
+	Notify bool   `json:"notify,omitempty"` // Post a message to the channels of the route
+
+	after time.Duration
+}
+
+// parseAutoResolve returns the auto-resolve settings of a route, inherited from the parent when it sets none
+func parseAutoResolve(after string, notify bool, parent *AutoResolve) (*AutoResolve, error) {
+	if after == "" {
+		return parent, nil
+	}
+
+	d, err := time.ParseDuration(after)
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid auto_resolve_after '%s'", after)
+	}
+
+	return &AutoResolve{After: after, Notify: notify, after: d}, nil
+}
+
+// Deadline returns when an incident received at t is resolved without a new notification
+func (a *AutoResolve) Deadline(t time.Time) time.Time {
+	return t.Add(a.after)
+}
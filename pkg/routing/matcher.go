@@ -0,0 +1,127 @@
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MatchType is the operator of a matcher
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher compares one field of the payload with a value, e.g. severity="critical"
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+
+	re *regexp.Regexp
+}
+
+// ParseMatcher parses a matcher in the Alertmanager syntax: name="value", name!="value",
+// name=~"regex" or name!~"regex". Quotes around the value are optional.
+func ParseMatcher(s string) (*Matcher, error) {
+	s = strings.TrimSpace(s)
+
+	idx := strings.IndexAny(s, "=!")
+	if idx <= 0 {
+		return nil, fmt.Errorf("invalid matcher '%s': expected name, operator and value", s)
+	}
+
+	m := &Matcher{Name: strings.TrimSpace(s[:idx])}
+
+	rest := s[idx:]
+	switch {
+	case strings.HasPrefix(rest, "=~"):
+		m.Type = MatchRegexp
+	case strings.HasPrefix(rest, "!~"):
+		m.Type = MatchNotRegexp
+	case strings.HasPrefix(rest, "!="):
+		m.Type = MatchNotEqual
+	case strings.HasPrefix(rest, "="):
+		m.Type = MatchEqual
+	default:
+		return nil, fmt.Errorf("invalid matcher '%s': unknown operator", s)
+	}
+
+	value := strings.TrimSpace(rest[len(m.Type):])
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher '%s': %w", s, err)
+		}
+		value = unquoted
+	}
+	m.Value = value
+
+	if m.Type == MatchRegexp || m.Type == MatchNotRegexp {
+		// Anchored like in Alertmanager, so "db" doesn't match "mongodb"
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher '%s': %w", s, err)
+		}
+		m.re = re
+	}
+
+	return m, nil
+}
+
+// ParseMatchers parses a list of matchers
+func ParseMatchers(list []string) ([]*Matcher, error) {
+	matchers := make([]*Matcher, 0, len(list))
+	for _, s := range list {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// Matches reports whether the field of the payload satisfies the matcher.
+// A missing field has the empty value, so env!="prod" matches payloads without env.
+func (m *Matcher) Matches(p *Payload) bool {
+	value := p.Value(m.Name)
+
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+
+	return false
+}
+
+// String returns the matcher in the syntax accepted by ParseMatcher
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// MarshalText shows matchers as strings in JSON responses
+func (m *Matcher) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// MatchAll reports whether the payload satisfies every matcher
+func MatchAll(matchers []*Matcher, p *Payload) bool {
+	for _, m := range matchers {
+		if !m.Matches(p) {
+			return false
+		}
+	}
+	return true
+}
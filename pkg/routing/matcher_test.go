@@ -0,0 +1,98 @@
+package routing
+
+import (
+	"testing"
+)
+
+func TestParseMatcher(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantName  string
+		wantType  MatchType
+		wantValue string
+		wantErr   bool
+	}{
+		{`severity="critical"`, "severity", MatchEqual, "critical", false},
+		{`env != "prod"`, "env", MatchNotEqual, "prod", false},
+		{`service=~"postgres|redis"`, "service", MatchRegexp, "postgres|redis", false},
+		{`service!~db.*`, "service", MatchNotRegexp, "db.*", false},
+		{`title="say \"hi\""`, "title", MatchEqual, `say "hi"`, false},
+		{`team=`, "team", MatchEqual, "", false},
+		{`="critical"`, "", "", "", true},
+		{`severity`, "", "", "", true},
+		{`severity!"critical"`, "", "", "", true},
+		{`severity="critical`, "", "", "", true},
+		{`service=~"("`, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMatcher(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMatcher(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if m.Name != tt.wantName || m.Type != tt.wantType || m.Value != tt.wantValue {
+			t.Errorf("ParseMatcher(%q) = %s %s %q, want %s %s %q", tt.input, m.Name, m.Type, m.Value, tt.wantName, tt.wantType, tt.wantValue)
+		}
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	content := map[string]interface{}{
+		"severity": "critical",
+		"commonLabels": map[string]interface{}{
+			"service": "postgres",
+		},
+		"labels": map[string]interface{}{
+			"env": "prod",
+		},
+		"alerts": []interface{}{
+			map[string]interface{}{"labels": map[string]interface{}{"instance": "db-1"}},
+		},
+		"count": 3,
+	}
+	p := NewPayload("payments", content)
+
+	tests := []struct {
+		matcher string
+		want    bool
+	}{
+		{`severity="critical"`, true},
+		{`severity!="critical"`, false},
+		{`service="postgres"`, true}, // From commonLabels
+		{`env="prod"`, true},         // From labels
+		{`alerts.0.labels.instance="db-1"`, true},
+		{`count="3"`, true},
+		{`team="payments"`, true},
+		{`service=~"postgres|redis"`, true},
+		{`service=~"post"`, false}, // Anchored
+		{`service!~"redis"`, true},
+		{`region!="eu"`, true}, // Missing fields are empty
+		{`region=""`, true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMatcher(tt.matcher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Matches(p); got != tt.want {
+			t.Errorf("%s matches = %v, want %v", tt.matcher, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherString(t *testing.T) {
+	for _, s := range []string{`severity="critical"`, `title!="say \"hi\""`, `service=~"postgres|redis"`} {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.String(); got != s {
+			t.Errorf("String() = %s, want %s", got, s)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package routing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VersusControl/versus-incident/pkg/sources"
+)
+
+// Payload is an incoming alert as seen by matchers
+type Payload struct {
+	Team    string
+	Content map[string]interface{}
+}
+
+// NewPayload creates a payload for matching
+func NewPayload(teamID string, content map[string]interface{}) *Payload {
+	return &Payload{Team: teamID, Content: content}
+}
+
+// Value returns a field of the payload as a string, or "" when it is missing.
+// Names with dots are paths into the payload, e.g. "labels.team" or "alerts.0.labels.severity".
+// Other names are looked up at the top level, then in commonLabels and labels, so
+// "severity" works for Alertmanager, Grafana and flat JSON payloads alike.
+// "team" is the team of the request and "source" the detected alert source, when the payload has none.
+func (p *Payload) Value(name string) string {
+	if name == "team" && p.Team != "" {
+		return p.Team
+	}
+
+	if strings.Contains(name, ".") {
+		return lookupPath(p.Content, strings.Split(name, "."))
+	}
+
+	for _, path := range [][]string{{name}, {"commonLabels", name}, {"labels", name}} {
+		if v := lookupPath(p.Content, path); v != "" {
+			return v
+		}
+	}
+
+	// The adapter that normalizes the payload for templates, so both agree on the source
+	if name == "source" {
+		return sources.Detect(p.Content).Name()
+	}
+
+	return ""
+}
+
+// Field returns the value at a path of the payload, e.g. "data.essentials.monitorCondition",
+// without the fallbacks of Value
+func (p *Payload) Field(path string) string {
+	return lookupPath(p.Content, strings.Split(path, "."))
+}
+
+// Fields returns the values of the commonly matched fields, for debugging routes
+func (p *Payload) Fields() map[string]string {
+	fields := make(map[string]string)
+	for _, name := range []string{"team", "source", "severity", "status"} {
+		fields[name] = p.Value(name)
+	}
+	return fields
+}
+
+func lookupPath(content map[string]interface{}, path []string) string {
+	var current interface{} = content
+
+	for _, key := range path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return ""
+			}
+			current = v[i]
+		default:
+			return ""
+		}
+	}
+
+	switch v := current.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,107 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/utils"
+)
+
+// QuietHours is when a team doesn't want to be paged
+type QuietHours struct {
+	Location          *time.Location
+	Start             time.Duration // Offset from midnight
+	End               time.Duration
+	Days              []time.Weekday
+	Except            []*Matcher
+	MuteNotifications bool
+}
+
+func parseQuietHours(qc *config.QuietHoursConfig) (*QuietHours, error) {
+	if qc == nil {
+		return nil, nil
+	}
+
+	location := time.Local
+	if qc.Timezone != "" {
+		loc, err := time.LoadLocation(qc.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours timezone '%s': %w", qc.Timezone, err)
+		}
+		location = loc
+	}
+
+	q := &QuietHours{Location: location, MuteNotifications: qc.MuteNotifications}
+
+	if (qc.Start == "") != (qc.End == "") {
+		return nil, fmt.Errorf("quiet hours need both start and end")
+	}
+
+	if qc.Start != "" {
+		start, err := parseClock(qc.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(qc.End)
+		if err != nil {
+			return nil, err
+		}
+		q.Start, q.End = start, end
+	}
+
+	for _, day := range qc.Days {
+		weekday, err := utils.ParseWeekday(day)
+		if err != nil {
+			return nil, err
+		}
+		q.Days = append(q.Days, weekday)
+	}
+
+	except, err := ParseMatchers(qc.Except)
+	if err != nil {
+		return nil, err
+	}
+	q.Except = except
+
+	return q, nil
+}
+
+// Active reports whether the payload arrives during quiet hours and isn't one of the exceptions
+func (q *QuietHours) Active(t time.Time, p *Payload) bool {
+	if q == nil || !q.contains(t) {
+		return false
+	}
+
+	return len(q.Except) == 0 || !MatchAll(q.Except, p)
+}
+
+func (q *QuietHours) contains(t time.Time) bool {
+	t = t.In(q.Location)
+
+	for _, day := range q.Days {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+
+	if q.Start == q.End {
+		return false
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.Start < q.End {
+		return clock >= q.Start && clock < q.End
+	}
+
+	// The window wraps around midnight, e.g. 22:00 - 07:00
+	return clock >= q.Start || clock < q.End
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,73 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	q, err := parseQuietHours(&config.QuietHoursConfig{
+		Timezone: "Asia/Ho_Chi_Minh",
+		Start:    "22:00",
+		End:      "07:00",
+		Days:     []string{"sat", "Sunday"},
+		Except:   []string{`severity="critical"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, location) // May 1st 2024 is a Wednesday
+	}
+	warning := NewPayload("", map[string]interface{}{"severity": "warning"})
+	critical := NewPayload("", map[string]interface{}{"severity": "critical"})
+
+	tests := []struct {
+		name    string
+		t       time.Time
+		payload *Payload
+		want    bool
+	}{
+		{"before the window", at(1, 21, 59), warning, false},
+		{"start of the window", at(1, 22, 0), warning, true},
+		{"after midnight", at(2, 6, 59), warning, true},
+		{"end of the window", at(2, 7, 0), warning, false},
+		{"quiet day", at(4, 12, 0), warning, true},
+		{"other timezone", time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC), warning, true}, // 22:30 in Ho Chi Minh City
+		{"exception", at(1, 23, 0), critical, false},
+	}
+
+	for _, tt := range tests {
+		if got := q.Active(tt.t, tt.payload); got != tt.want {
+			t.Errorf("%s: Active(%s) = %v, want %v", tt.name, tt.t, got, tt.want)
+		}
+	}
+
+	var none *QuietHours
+	if none.Active(at(1, 23, 0), warning) {
+		t.Error("Active() without quiet hours = true")
+	}
+}
+
+func TestParseQuietHoursErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		qc   config.QuietHoursConfig
+	}{
+		{"start without end", config.QuietHoursConfig{Start: "22:00"}},
+		{"invalid time", config.QuietHoursConfig{Start: "10pm", End: "07:00"}},
+		{"invalid day", config.QuietHoursConfig{Days: []string{"weekend"}}},
+		{"invalid timezone", config.QuietHoursConfig{Timezone: "Mars/Olympus"}},
+		{"invalid exception", config.QuietHoursConfig{Except: []string{"severity"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseQuietHours(&tt.qc); err == nil {
+			t.Errorf("parseQuietHours() with %s succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package routing
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/grouping"
+	"github.com/VersusControl/versus-incident/pkg/storm"
+)
+
+// Alert providers that routes can select
+var alertProviders = []string{"slack", "telegram", "viber", "email", "msteams", "lark"}
+
+// Route is a node of the routing tree with the settings inherited from its parents
+type Route struct {
+	Name        string                    `json:"name"`
+	Path        []string                  `json:"path"`
+	Matchers    []*Matcher                `json:"matchers,omitempty"`
+	Providers   []string                  `json:"providers,omitempty"`
+	Channels    map[string]string         `json:"channels,omitempty"`
+	Templates   map[string]string         `json:"templates,omitempty"`
+	OnCall      *config.RouteOnCallConfig `json:"oncall,omitempty"`
+	QuietHours  *QuietHours               `json:"-"`
+	Storm       *storm.Policy             `json:"storm,omitempty"`
+	Group       *grouping.Policy          `json:"group,omitempty"`
+	Split       bool                      `json:"split_alerts,omitempty"`
+	AutoResolve *AutoResolve              `json:"auto_resolve,omitempty"`
+	Continue    bool                      `json:"continue"`
+	Routes      []*Route                  `json:"-"`
+}
+
+// Router matches incoming alerts against the routing tree
+type Router struct {
+	root  *Route
+	teams map[string]*Route // Each team has its own tree, inheriting the team settings
+}
+
+// Global instance for singleton access
+var (
+	router     *Router
+	routerOnce sync.Once
+)
+
+// NewRouter builds the routing tree from the configured routes and teams
+func NewRouter(routes []config.RouteConfig, teams []config.TeamConfig) (*Router, error) {
+	root := &Route{Name: "default", Path: []string{"default"}}
+	if err := addRoutes(root, routes); err != nil {
+		return nil, err
+	}
+
+	r := &Router{root: root, teams: make(map[string]*Route)}
+
+	for _, tc := range teams {
+		if tc.ID == "" {
+			return nil, fmt.Errorf("team without id")
+		}
+
+		if err := validateProviders("team '"+tc.ID+"'", tc.Providers, tc.Templates); err != nil {
+			return nil, err
+		}
+
+		quietHours, err := parseQuietHours(tc.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("team '%s': %w", tc.ID, err)
+		}
+
+		stormPolicy, err := storm.ParsePolicy(tc.Storm)
+		if err != nil {
+			return nil, fmt.Errorf("team '%s': %w", tc.ID, err)
+		}
+
+		groupPolicy, err := grouping.ParsePolicy(tc.GroupBy, tc.GroupWait, tc.GroupInterval, nil)
+		if err != nil {
+			return nil, fmt.Errorf("team '%s': %w", tc.ID, err)
+		}
+
+		autoResolve, err := parseAutoResolve(tc.AutoResolveAfter, tc.AutoResolveNotify, nil)
+		if err != nil {
+			return nil, fmt.Errorf("team '%s': %w", tc.ID, err)
+		}
+
+		if tc.OnCall != nil {
+			if err := config.ValidateOnCallMode(tc.OnCall.Mode); err != nil {
+				return nil, fmt.Errorf("team '%s' oncall: %w", tc.ID, err)
+			}
+		}
+
+		team := &Route{
+			Name:        tc.ID,
+			Path:        []string{tc.ID},
+			Providers:   tc.Providers,
+			Channels:    tc.Channels,
+			Templates:   tc.Templates,
+			OnCall:      tc.OnCall,
+			QuietHours:  quietHours,
+			Storm:       stormPolicy,
+			Group:       groupPolicy,
+			Split:       tc.SplitAlerts != nil && *tc.SplitAlerts,
+			AutoResolve: autoResolve,
+		}
+		if err := addRoutes(team, routes); err != nil {
+			return nil, err
+		}
+
+		r.teams[tc.ID] = team
+	}
+
+	return r, nil
+}
+
+// InitRouter initializes the global singleton instance
+func InitRouter(routes []config.RouteConfig, teams []config.TeamConfig) error {
+	var err error
+
+	routerOnce.Do(func() {
+		router, err = NewRouter(routes, teams)
+		if err == nil && (len(routes) > 0 || len(teams) > 0) {
+			log.Printf("Routing initialized with %d top-level routes and %d teams", len(routes), len(teams))
+		}
+	})
+
+	return err
+}
+
+// GetRouter returns the global singleton instance, or nil when it isn't initialized
+func GetRouter() *Router {
+	return router
+}
+
+// AutoResolves reports whether a route or a team resolves its silent incidents
+func (r *Router) AutoResolves() bool {
+	if r.root.autoResolves() {
+		return true
+	}
+	for _, team := range r.teams {
+		if team.autoResolves() {
+			return true
+		}
+	}
+	return false
+}
+
+// EnablesOnCall reports whether a route or a team sets oncall.enable, the on-call workflow
+// is needed then even when on-call is disabled globally
+func (r *Router) EnablesOnCall() bool {
+	if r.root.enablesOnCall() {
+		return true
+	}
+	for _, team := range r.teams {
+		if team.enablesOnCall() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Route) enablesOnCall() bool {
+	if r.OnCall != nil && r.OnCall.Enable != nil && *r.OnCall.Enable {
+		return true
+	}
+	for _, child := range r.Routes {
+		if child.enablesOnCall() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Route) autoResolves() bool {
+	if r.AutoResolve != nil {
+		return true
+	}
+	for _, child := range r.Routes {
+		if child.autoResolves() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTeam reports whether a team is configured
+func (r *Router) HasTeam(id string) bool {
+	_, ok := r.teams[id]
+	return ok
+}
+
+func addRoutes(parent *Route, routes []config.RouteConfig) error {
+	for i, rc := range routes {
+		child, err := newRoute(rc, parent, i)
+		if err != nil {
+			return err
+		}
+		parent.Routes = append(parent.Routes, child)
+	}
+	return nil
+}
+
+func newRoute(rc config.RouteConfig, parent *Route, index int) (*Route, error) {
+	name := rc.Name
+	if name == "" {
+		name = fmt.Sprintf("%s.%d", parent.Name, index)
+	}
+
+	matchers, err := ParseMatchers(rc.Matchers)
+	if err != nil {
+		return nil, fmt.Errorf("route '%s': %w", name, err)
+	}
+
+	if err := validateProviders("route '"+name+"'", rc.Providers, rc.Templates); err != nil {
+		return nil, err
+	}
+
+	stormPolicy, err := storm.ParsePolicy(rc.Storm)
+	if err != nil {
+		return nil, fmt.Errorf("route '%s': %w", name, err)
+	}
+	if stormPolicy == nil {
+		stormPolicy = parent.Storm
+	}
+
+	groupPolicy, err := grouping.ParsePolicy(rc.GroupBy, rc.GroupWait, rc.GroupInterval, parent.Group)
+	if err != nil {
+		return nil, fmt.Errorf("route '%s': %w", name, err)
+	}
+
+	autoResolve, err := parseAutoResolve(rc.AutoResolveAfter, rc.AutoResolveNotify, parent.AutoResolve)
+	if err != nil {
+		return nil, fmt.Errorf("route '%s': %w", name, err)
+	}
+
+	if rc.OnCall != nil {
+		if err := config.ValidateOnCallMode(rc.OnCall.Mode); err != nil {
+			return nil, fmt.Errorf("route '%s' oncall: %w", name, err)
+		}
+	}
+
+	r := &Route{
+		Name:        name,
+		Path:        append(slices.Clone(parent.Path), name),
+		Matchers:    matchers,
+		Providers:   parent.Providers,
+		Channels:    mergeMaps(parent.Channels, rc.Channels),
+		Templates:   mergeMaps(parent.Templates, rc.Templates),
+		OnCall:      mergeOnCall(parent.OnCall, rc.OnCall),
+		QuietHours:  parent.QuietHours,
+		Storm:       stormPolicy,
+		Group:       groupPolicy,
+		Split:       parent.Split,
+		AutoResolve: autoResolve,
+		Continue:    rc.Continue,
+	}
+
+	if rc.SplitAlerts != nil {
+		r.Split = *rc.SplitAlerts
+	}
+
+	if len(rc.Providers) > 0 {
+		r.Providers = rc.Providers
+	}
+
+	if err := addRoutes(r, rc.Routes); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func validateProviders(owner string, providers []string, templates map[string]string) error {
+	for _, p := range providers {
+		if !slices.Contains(alertProviders, p) {
+			return fmt.Errorf("%s: unknown provider '%s'", owner, p)
+		}
+	}
+
+	for p := range templates {
+		if !slices.Contains(alertProviders, p) {
+			return fmt.Errorf("%s: unknown template provider '%s'", owner, p)
+		}
+	}
+
+	return nil
+}
+
+// Match returns the routes the payload ends up on. Like in Alertmanager, the first matching
+// child wins unless it has continue set, and a route without matching children is used itself.
+// The default route, or the team of the payload, is returned when nothing matches.
+func (r *Router) Match(p *Payload) []*Route {
+	if team, ok := r.teams[p.Team]; ok {
+		return team.match(p)
+	}
+	return r.root.match(p)
+}
+
+func (r *Route) match(p *Payload) []*Route {
+	var matched []*Route
+
+	for _, child := range r.Routes {
+		if !MatchAll(child.Matchers, p) {
+			continue
+		}
+
+		matched = append(matched, child.match(p)...)
+		if !child.Continue {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		return []*Route{r}
+	}
+
+	return matched
+}
+
+// Params returns the channels and escalation policy of the route as query parameters,
+// so they are applied the same way as /api/incidents?slack_channel_id=...
+func (r *Route) Params() map[string]string {
+	params := make(map[string]string, len(r.Channels))
+	for k, v := range r.Channels {
+		params[k] = v
+	}
+
+	if oc := r.OnCall; oc != nil {
+		if oc.Enable != nil {
+			params["oncall_enable"] = strconv.FormatBool(*oc.Enable)
+		}
+		if oc.WaitMinutes != nil {
+			params["oncall_wait_minutes"] = strconv.Itoa(*oc.WaitMinutes)
+		}
+		if len(oc.Providers) > 0 {
+			params["oncall_providers"] = strings.Join(oc.Providers, ",")
+		}
+		if oc.Mode != "" {
+			params["oncall_mode"] = oc.Mode
+		}
+		if oc.Schedule != "" {
+			params["oncall_schedule"] = oc.Schedule
+		}
+	}
+
+	return params
+}
+
+// Key identifies the route, e.g. for the storm detector
+func (r *Route) Key() string {
+	return strings.Join(r.Path, "/")
+}
+
+// GroupKey returns the group of the payload on the route, from the values of its group_by fields
+func (r *Route) GroupKey(p *Payload) string {
+	values := make([]string, len(r.Group.By))
+	for i, name := range r.Group.By {
+		values[i] = name + "=" + strconv.Quote(p.Value(name))
+	}
+	return r.Key() + "{" + strings.Join(values, ",") + "}"
+}
+
+// ApplyTemplates sets the template paths of the route on a per-incident config
+func (r *Route) ApplyTemplates(cfg *config.Config) {
+	SetTemplates(cfg, r.Templates)
+}
+
+// SetTemplates sets the template paths of the providers on a per-incident config
+func SetTemplates(cfg *config.Config, templates map[string]string) {
+	for provider, path := range templates {
+		switch provider {
+		case "slack":
+			cfg.Alert.Slack.TemplatePath = path
+		case "telegram":
+			cfg.Alert.Telegram.TemplatePath = path
+		case "viber":
+			cfg.Alert.Viber.TemplatePath = path
+		case "email":
+			cfg.Alert.Email.TemplatePath = path
+		case "msteams":
+			cfg.Alert.MSTeams.TemplatePath = path
+		case "lark":
+			cfg.Alert.Lark.TemplatePath = path
+		}
+	}
+}
+
+func mergeMaps(parent, child map[string]string) map[string]string {
+	if len(child) == 0 {
+		return parent
+	}
+
+	merged := make(map[string]string, len(parent)+len(child))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range child {
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeOnCall(parent, child *config.RouteOnCallConfig) *config.RouteOnCallConfig {
+	if child == nil {
+		return parent
+	}
+	if parent == nil {
+		return child
+	}
+
+	merged := *parent
+	if child.Enable != nil {
+		merged.Enable = child.Enable
+	}
+	if child.WaitMinutes != nil {
+		merged.WaitMinutes = child.WaitMinutes
+	}
+	if len(child.Providers) > 0 {
+		merged.Providers = child.Providers
+	}
+	if child.Mode != "" {
+		merged.Mode = child.Mode
+	}
+	if child.Schedule != "" {
+		merged.Schedule = child.Schedule
+	}
+	return &merged
+}
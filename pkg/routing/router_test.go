@@ -0,0 +1,86 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+func TestRouterMatch(t *testing.T) {
+	routes := []config.RouteConfig{
+		{
+			Name:     "database",
+			Matchers: []string{`service=~"postgres|redis"`},
+			Channels: map[string]string{"slack_channel_id": "C0DB"},
+			Routes: []config.RouteConfig{
+				{Name: "database-critical", Matchers: []string{`severity="critical"`}, Channels: map[string]string{"email_to": "dba@example.com"}},
+			},
+		},
+		{Name: "audit", Matchers: []string{`env="prod"`}, Continue: true},
+		{Name: "prod", Matchers: []string{`env="prod"`}},
+		{Name: "never", Matchers: []string{`env="prod"`}}, // After a route without continue
+		{Name: "payments", Matchers: []string{`team="payments"`}},
+	}
+	teams := []config.TeamConfig{
+		{ID: "payments", Channels: map[string]string{"slack_channel_id": "C0PAY"}},
+	}
+
+	r, err := NewRouter(routes, teams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		team         string
+		content      map[string]interface{}
+		wantRoutes   []string
+		wantChannels map[string]string // Of the first route
+	}{
+		{"default", "", map[string]interface{}{"service": "api"}, []string{"default"}, map[string]string{}},
+		{"parent without matching child", "", map[string]interface{}{"service": "redis"}, []string{"database"}, map[string]string{"slack_channel_id": "C0DB"}},
+		{"child inherits channels", "", map[string]interface{}{"service": "postgres", "severity": "critical"}, []string{"database-critical"}, map[string]string{"slack_channel_id": "C0DB", "email_to": "dba@example.com"}},
+		{"continue", "", map[string]interface{}{"env": "prod"}, []string{"audit", "prod"}, map[string]string{}},
+		{"team default", "payments", map[string]interface{}{"service": "api"}, []string{"payments"}, map[string]string{"slack_channel_id": "C0PAY"}},
+		{"team route overrides team channels", "payments", map[string]interface{}{"service": "redis"}, []string{"database"}, map[string]string{"slack_channel_id": "C0DB"}},
+		{"team matcher", "payments", map[string]interface{}{"service": "api", "severity": "info"}, []string{"payments"}, map[string]string{"slack_channel_id": "C0PAY"}},
+		{"unknown team uses the default tree", "search", map[string]interface{}{"service": "api"}, []string{"default"}, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := r.Match(NewPayload(tt.team, tt.content))
+
+			var names []string
+			for _, route := range matched {
+				names = append(names, route.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantRoutes) {
+				t.Fatalf("Match() = %v, want %v", names, tt.wantRoutes)
+			}
+			if params := matched[0].Params(); !reflect.DeepEqual(params, tt.wantChannels) {
+				t.Errorf("Params() = %v, want %v", params, tt.wantChannels)
+			}
+		})
+	}
+}
+
+func TestNewRouterErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []config.RouteConfig
+		teams  []config.TeamConfig
+	}{
+		{"invalid matcher", []config.RouteConfig{{Name: "db", Matchers: []string{"service"}}}, nil},
+		{"unknown provider", []config.RouteConfig{{Name: "db", Providers: []string{"pager"}}}, nil},
+		{"team without id", nil, []config.TeamConfig{{}}},
+		{"invalid quiet hours", nil, []config.TeamConfig{{ID: "payments", QuietHours: &config.QuietHoursConfig{Start: "22:00"}}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewRouter(tt.routes, tt.teams); err == nil {
+			t.Errorf("NewRouter() with %s succeeded", tt.name)
+		}
+	}
+}
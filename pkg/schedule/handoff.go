@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// Replicas sharing the schedules claim each handoff, so it is notified once
+	redisHandoffPrefix = "oncall_handoff:"
+	handoffClaimTTL    = 24 * time.Hour
+)
+
+// HandoffNotifier tells the incoming person that their shift started
+type HandoffNotifier func(incoming *Shift, outgoing *Shift) error
+
+// StartHandoffWatcher checks the schedules every interval and notifies the incoming person
+// of the schedules with notify_handoff enabled. With Redis, only the replica that claims
+// the handoff notifies it. Call the returned function to stop it.
+func (s *Store) StartHandoffWatcher(notify HandoffNotifier, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	// Remember who is on call now, so the first tick doesn't notify
+	current := make(map[string]*Shift)
+	now := time.Now()
+	for _, sched := range s.List() {
+		current[sched.Name] = sched.OnCallAt(now)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				for _, sched := range s.List() {
+					shift := sched.OnCallAt(now)
+					previous := current[sched.Name]
+					current[sched.Name] = shift
+
+					if !sched.NotifyHandoff || shift == nil || sameUser(shift, previous) {
+						continue
+					}
+
+					if !s.claimHandoff(shift) {
+						continue
+					}
+
+					log.Printf("On-call handoff for schedule '%s': %s is now on call", sched.Name, shift.User.Name)
+					if err := notify(shift, previous); err != nil {
+						log.Printf("Failed to notify on-call handoff for schedule '%s': %v", sched.Name, err)
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// claimHandoff reports whether this replica notifies the handoff to the shift, the first one to claim it does
+func (s *Store) claimHandoff(shift *Shift) bool {
+	if s.redisClient == nil {
+		return true
+	}
+
+	key := fmt.Sprintf("%s%s:%d:%s", redisHandoffPrefix, shift.Schedule, shift.Start.Unix(), shift.User.ID)
+	claimed, err := s.redisClient.SetNX(context.Background(), key, "1", handoffClaimTTL).Result()
+	if err != nil {
+		// Better notify twice than not at all
+		log.Printf("Failed to claim the on-call handoff of schedule '%s' in Redis: %v", shift.Schedule, err)
+		return true
+	}
+
+	return claimed
+}
+
+func sameUser(a, b *Shift) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.User.ID == b.User.ID
+}
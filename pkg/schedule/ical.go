@@ -0,0 +1,129 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	icalTimeFormat = "20060102T150405Z"
+
+	// Handoffs looked up at most before and after the calendar window, a rotation
+	// with a single user would otherwise never end
+	maxAlignSteps = 366
+)
+
+// Calendar renders the shifts between from and to as an RFC 5545 iCalendar feed.
+// When userID is set, only that user's shifts are included.
+func (s *Schedule) Calendar(from, to time.Time, userID string) []byte {
+	var b strings.Builder
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Versus Incident//On-Call Schedules//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText("On-call: "+s.Name))
+	writeICalLine(&b, "X-WR-TIMEZONE:"+s.Location.String())
+
+	stamp := time.Now().UTC().Format(icalTimeFormat)
+
+	start, end := s.alignWindow(from, to)
+
+	for _, shift := range s.Shifts(start, end) {
+		if !shift.End.After(from) || (userID != "" && shift.User.ID != userID) {
+			continue
+		}
+
+		summary := fmt.Sprintf("On call: %s (%s)", shift.User.Name, s.Name)
+		description := "Layer: " + shift.Layer
+		if shift.Override {
+			summary += " - override"
+			description = "Override"
+		}
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		// The UID stays the same for the same shift, so calendar clients update instead of duplicating
+		writeICalLine(&b, fmt.Sprintf("UID:%s-%s-%d@versus-incident", s.Name, shift.User.ID, shift.Start.Unix()))
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		writeICalLine(&b, "DTSTART:"+shift.Start.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTEND:"+shift.End.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(summary))
+		writeICalLine(&b, "DESCRIPTION:"+escapeICalText(description))
+		if shift.User.Email != "" {
+			writeICalLine(&b, fmt.Sprintf("ATTENDEE;CN=%s:mailto:%s", escapeICalParam(shift.User.Name), shift.User.Email))
+		}
+		writeICalLine(&b, "TRANSP:TRANSPARENT")
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// alignWindow moves from and to out to the start and end of the shifts running at those times,
+// so the first and last events keep their real start, end and UID whenever the feed is fetched
+func (s *Schedule) alignWindow(from, to time.Time) (time.Time, time.Time) {
+	for i := 0; i < maxAlignSteps; i++ {
+		prev := s.OnCallAt(from.Add(-time.Nanosecond))
+		if !sameShiftUser(prev, s.OnCallAt(from)) {
+			break
+		}
+		from = prev.Start
+	}
+
+	for i := 0; i < maxAlignSteps; i++ {
+		next := s.OnCallAt(to)
+		if !sameShiftUser(s.OnCallAt(to.Add(-time.Nanosecond)), next) {
+			break
+		}
+		to = next.End
+	}
+
+	return from, to
+}
+
+// sameShiftUser reports whether two adjacent shifts are merged into one by Shifts
+func sameShiftUser(a, b *Shift) bool {
+	return a != nil && b != nil && a.User.ID == b.User.ID && a.Override == b.Override
+}
+
+// writeICalLine writes a content line, folded at 75 octets as required by RFC 5545.
+// Continuation lines start with a space, which counts in their 75 octets.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+
+	for len(line) > limit {
+		cut := limit
+		// Don't split a multi-byte UTF-8 character
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func escapeICalText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+func escapeICalParam(value string) string {
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return value
+}
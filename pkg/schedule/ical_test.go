@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteICalLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "SUMMARY:On call", "SUMMARY:On call\r\n"},
+		{"75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"continuation lines", strings.Repeat("a", 75+74+1), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		{"multi-byte character", strings.Repeat("a", 74) + "é", strings.Repeat("a", 74) + "\r\n é\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICalLine(&b, tt.line)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeICalLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeICal(t *testing.T) {
+	if got := escapeICalText("a,b;c\\d\r\ne\nf"); got != `a\,b\;c\\d\ne\nf` {
+		t.Errorf("escapeICalText() = %s", got)
+	}
+	if got := escapeICalParam("Doe, Jane"); got != `"Doe, Jane"` {
+		t.Errorf("escapeICalParam() = %s", got)
+	}
+	if got := escapeICalParam("Jane"); got != "Jane" {
+		t.Errorf("escapeICalParam() = %s", got)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	s := newTestSchedule(t)
+
+	// From the middle of a day shift of alice to the middle of the shift of bob, for alice only
+	feed := string(s.Calendar(at(1, 4, 13, 0), at(1, 8, 12, 0), "alice"))
+
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	if n := strings.Count(unfolded, "BEGIN:VEVENT"); n != 5 {
+		t.Errorf("Calendar() has %d events, want 5:\n%s", n, unfolded)
+	}
+	if strings.Contains(unfolded, "bob") || strings.Contains(unfolded, "carol") || strings.Contains(unfolded, "dave") {
+		t.Errorf("Calendar() has the shifts of other users:\n%s", unfolded)
+	}
+
+	// The shift running at the start of the window keeps its real start, so its UID is stable
+	if !strings.Contains(unfolded, "DTSTART:20240104T060000Z") {
+		t.Errorf("Calendar() doesn't start the first shift at its handoff:\n%s", unfolded)
+	}
+	if !strings.Contains(unfolded, "SUMMARY:On call: Alice (payments)") {
+		t.Errorf("Calendar() has no summary for Alice:\n%s", unfolded)
+	}
+}
@@ -0,0 +1,395 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/utils"
+)
+
+// User is a person that can be on call
+type User struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email,omitempty"`
+	SlackUserID string `json:"slack_user_id,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+}
+
+// Shift is a period during which one user is on call
+type Shift struct {
+	Schedule string    `json:"schedule"`
+	User     *User     `json:"user"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Layer    string    `json:"layer,omitempty"`
+	Override bool      `json:"override"`
+}
+
+// Schedule is a parsed on-call schedule, ready to answer who is on call at a given time
+type Schedule struct {
+	Name          string
+	NotifyHandoff bool
+	Location      *time.Location
+
+	definition config.ScheduleConfig
+	layers     []*layer
+	overrides  []*override
+	users      map[string]*User
+}
+
+type layer struct {
+	name     string
+	period   int // Rotation length in days
+	anchor   time.Time
+	users    []string
+	restrict *dailyWindow
+}
+
+type override struct {
+	user  string
+	start time.Time
+	end   time.Time
+}
+
+// dailyWindow restricts a layer to a time of day, it may wrap around midnight (e.g. 18:00-09:00)
+type dailyWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// New parses a schedule definition, users are looked up by ID for names and contact details
+func New(def config.ScheduleConfig, users map[string]*User) (*Schedule, error) {
+	if def.Name == "" {
+		return nil, fmt.Errorf("schedule name is required")
+	}
+
+	location := time.Local
+	if def.Timezone != "" {
+		loc, err := time.LoadLocation(def.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone '%s' for schedule '%s': %w", def.Timezone, def.Name, err)
+		}
+		location = loc
+	}
+
+	s := &Schedule{
+		Name:          def.Name,
+		NotifyHandoff: def.NotifyHandoff,
+		Location:      location,
+		definition:    def,
+		users:         users,
+	}
+
+	if len(def.Layers) == 0 {
+		return nil, fmt.Errorf("schedule '%s' has no layers", def.Name)
+	}
+
+	for i, lc := range def.Layers {
+		l, err := parseLayer(lc, location)
+		if err != nil {
+			return nil, fmt.Errorf("schedule '%s' layer %d: %w", def.Name, i+1, err)
+		}
+		s.layers = append(s.layers, l)
+	}
+
+	for i, oc := range def.Overrides {
+		o, err := parseOverride(oc, location)
+		if err != nil {
+			return nil, fmt.Errorf("schedule '%s' override %d: %w", def.Name, i+1, err)
+		}
+		s.overrides = append(s.overrides, o)
+	}
+
+	return s, nil
+}
+
+// Definition returns the configuration the schedule was built from
+func (s *Schedule) Definition() config.ScheduleConfig {
+	return s.definition
+}
+
+// OnCallAt returns the shift covering t, or nil if nobody is on call.
+// Overrides win over layers and later layers win over earlier ones.
+func (s *Schedule) OnCallAt(t time.Time) *Shift {
+	t = t.In(s.Location)
+
+	// The most recently added override wins
+	for i := len(s.overrides) - 1; i >= 0; i-- {
+		o := s.overrides[i]
+		if !t.Before(o.start) && t.Before(o.end) {
+			return &Shift{
+				Schedule: s.Name,
+				User:     s.user(o.user),
+				Start:    o.start,
+				End:      o.end,
+				Override: true,
+			}
+		}
+	}
+
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		l := s.layers[i]
+		if userID, start, end, ok := l.shiftAt(t); ok {
+			return &Shift{
+				Schedule: s.Name,
+				User:     s.user(userID),
+				Start:    start,
+				End:      end,
+				Layer:    l.name,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Shifts returns the final on-call shifts between from and to, after applying layers and overrides
+func (s *Schedule) Shifts(from, to time.Time) []Shift {
+	from = from.In(s.Location)
+	to = to.In(s.Location)
+
+	// Every point where the on-call person may change
+	boundaries := []time.Time{from}
+	for _, l := range s.layers {
+		boundaries = append(boundaries, l.boundaries(from, to)...)
+	}
+	for _, o := range s.overrides {
+		boundaries = append(boundaries, o.start, o.end)
+	}
+
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	var shifts []Shift
+	for i, start := range boundaries {
+		if start.Before(from) || !start.Before(to) {
+			continue
+		}
+		if i > 0 && start.Equal(boundaries[i-1]) {
+			continue
+		}
+
+		end := to
+		for _, b := range boundaries[i+1:] {
+			if b.After(start) {
+				if b.Before(to) {
+					end = b
+				}
+				break
+			}
+		}
+
+		shift := s.OnCallAt(start)
+		if shift == nil {
+			continue
+		}
+		shift.Start, shift.End = start, end
+
+		// Merge with the previous segment when the same user continues
+		if n := len(shifts); n > 0 && shifts[n-1].End.Equal(start) && shifts[n-1].User.ID == shift.User.ID && shifts[n-1].Override == shift.Override {
+			shifts[n-1].End = end
+			continue
+		}
+
+		shifts = append(shifts, *shift)
+	}
+
+	return shifts
+}
+
+func (s *Schedule) user(id string) *User {
+	if u, ok := s.users[id]; ok {
+		return u
+	}
+	// Unknown users are still shown by their ID
+	return &User{ID: id, Name: id}
+}
+
+func parseLayer(lc config.ScheduleLayerConfig, location *time.Location) (*layer, error) {
+	if len(lc.Users) == 0 {
+		return nil, fmt.Errorf("no users in rotation")
+	}
+
+	var period int
+	switch strings.ToLower(lc.Rotation) {
+	case "daily":
+		period = 1
+	case "weekly", "":
+		period = 7
+	default:
+		return nil, fmt.Errorf("unsupported rotation '%s', expected daily or weekly", lc.Rotation)
+	}
+
+	handoff, err := parseClock(lc.HandoffTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid handoff_time: %w", err)
+	}
+
+	// The start date anchors the rotation, so it stays the same across restarts
+	if lc.Start == "" {
+		return nil, fmt.Errorf("start date is required")
+	}
+
+	startDay, err := time.ParseInLocation("2006-01-02", lc.Start, location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date '%s', expected YYYY-MM-DD: %w", lc.Start, err)
+	}
+
+	anchor := atClock(startDay, handoff)
+
+	// Weekly rotations hand off on the configured weekday
+	if period == 7 && lc.HandoffDay != "" {
+		weekday, err := utils.ParseWeekday(lc.HandoffDay)
+		if err != nil {
+			return nil, fmt.Errorf("handoff_day: %w", err)
+		}
+		for anchor.Weekday() != weekday {
+			anchor = anchor.AddDate(0, 0, 1)
+		}
+	}
+
+	l := &layer{
+		name:   lc.Name,
+		period: period,
+		anchor: anchor,
+		users:  lc.Users,
+	}
+
+	if lc.RestrictStart != "" || lc.RestrictEnd != "" {
+		start, err := parseClock(lc.RestrictStart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid restrict_start: %w", err)
+		}
+		end, err := parseClock(lc.RestrictEnd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid restrict_end: %w", err)
+		}
+		l.restrict = &dailyWindow{start: start, end: end}
+	}
+
+	return l, nil
+}
+
+// shiftAt returns the user of the rotation at t together with the rotation shift bounds
+func (l *layer) shiftAt(t time.Time) (string, time.Time, time.Time, bool) {
+	if t.Before(l.anchor) {
+		return "", time.Time{}, time.Time{}, false
+	}
+
+	if l.restrict != nil && !l.restrict.contains(t) {
+		return "", time.Time{}, time.Time{}, false
+	}
+
+	index := l.index(t)
+	start := l.anchor.AddDate(0, 0, index*l.period)
+	end := start.AddDate(0, 0, l.period)
+
+	return l.users[index%len(l.users)], start, end, true
+}
+
+// index counts the handoffs between the anchor and t, using calendar days so DST changes don't shift handoffs
+func (l *layer) index(t time.Time) int {
+	days := daysBetween(l.anchor, t)
+	index := days / l.period
+
+	// Before the handoff time on the handoff day, the previous shift is still running
+	if l.anchor.AddDate(0, 0, index*l.period).After(t) {
+		index--
+	}
+
+	return index
+}
+
+// boundaries returns the handoffs and restriction edges of the layer between from and to
+func (l *layer) boundaries(from, to time.Time) []time.Time {
+	var result []time.Time
+
+	start := l.anchor
+	if from.After(start) {
+		start = l.anchor.AddDate(0, 0, l.index(from)*l.period)
+	}
+	for t := start; t.Before(to); t = t.AddDate(0, 0, l.period) {
+		result = append(result, t)
+	}
+
+	if l.restrict != nil {
+		for day := atClock(from, 0).AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+			result = append(result, day.Add(l.restrict.start), day.Add(l.restrict.end))
+		}
+	}
+
+	return result
+}
+
+func (w *dailyWindow) contains(t time.Time) bool {
+	clock := t.Sub(atClock(t, 0))
+
+	if w.start <= w.end {
+		return clock >= w.start && clock < w.end
+	}
+
+	// Window wraps around midnight
+	return clock >= w.start || clock < w.end
+}
+
+func parseOverride(oc config.ScheduleOverrideConfig, location *time.Location) (*override, error) {
+	if oc.User == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	start, err := parseTime(oc.Start, location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+
+	end, err := parseTime(oc.End, location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	return &override{user: oc.User, start: start, end: end}, nil
+}
+
+// parseTime accepts RFC 3339 or a local "2006-01-02T15:04" time in the schedule timezone
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(location), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", value, location)
+}
+
+// parseClock parses "HH:MM" into the offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got '%s'", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// atClock returns t's calendar day at the given time of day
+func atClock(t time.Time, clock time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+		int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days from a to b in a's location
+func daysBetween(a, b time.Time) int {
+	b = b.In(a.Location())
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
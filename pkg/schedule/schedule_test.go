@@ -0,0 +1,144 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+func newTestSchedule(t *testing.T) *Schedule {
+	t.Helper()
+
+	s, err := New(config.ScheduleConfig{
+		Name:     "payments",
+		Timezone: "UTC",
+		Layers: []config.ScheduleLayerConfig{
+			{Name: "primary", Rotation: "weekly", Start: "2024-01-01", HandoffTime: "09:00", HandoffDay: "mon", Users: []string{"alice", "bob"}},
+			{Name: "night", Rotation: "daily", Start: "2024-01-01", Users: []string{"carol"}, RestrictStart: "22:00", RestrictEnd: "06:00"},
+		},
+		Overrides: []config.ScheduleOverrideConfig{
+			{User: "dave", Start: "2024-01-03T12:00", End: "2024-01-03T14:00"},
+		},
+	}, map[string]*User{"alice": {ID: "alice", Name: "Alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestOnCallAt(t *testing.T) {
+	s := newTestSchedule(t)
+
+	tests := []struct {
+		name         string
+		t            time.Time
+		wantUser     string
+		wantLayer    string
+		wantOverride bool
+		wantStart    time.Time
+	}{
+		{name: "before the rotation starts", t: at(1, 1, 8, 59)},
+		{name: "first shift", t: at(1, 1, 9, 0), wantUser: "alice", wantLayer: "primary", wantStart: at(1, 1, 9, 0)},
+		{name: "before the handoff time", t: at(1, 8, 8, 59), wantUser: "alice", wantLayer: "primary", wantStart: at(1, 1, 9, 0)},
+		{name: "second shift", t: at(1, 8, 9, 0), wantUser: "bob", wantLayer: "primary", wantStart: at(1, 8, 9, 0)},
+		{name: "rotation wraps", t: at(1, 15, 10, 0), wantUser: "alice", wantLayer: "primary", wantStart: at(1, 15, 9, 0)},
+		{name: "later layer wins", t: at(1, 2, 23, 0), wantUser: "carol", wantLayer: "night", wantStart: at(1, 2, 0, 0)},
+		{name: "restricted window wraps around midnight", t: at(1, 3, 5, 59), wantUser: "carol", wantLayer: "night", wantStart: at(1, 3, 0, 0)},
+		{name: "override wins", t: at(1, 3, 13, 0), wantUser: "dave", wantOverride: true, wantStart: at(1, 3, 12, 0)},
+		{name: "override ended", t: at(1, 3, 14, 0), wantUser: "alice", wantLayer: "primary", wantStart: at(1, 1, 9, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift := s.OnCallAt(tt.t)
+			if tt.wantUser == "" {
+				if shift != nil {
+					t.Errorf("OnCallAt() = %s, want nobody", shift.User.ID)
+				}
+				return
+			}
+			if shift == nil {
+				t.Fatalf("OnCallAt() = nobody, want %s", tt.wantUser)
+			}
+			if shift.User.ID != tt.wantUser || shift.Layer != tt.wantLayer || shift.Override != tt.wantOverride || !shift.Start.Equal(tt.wantStart) {
+				t.Errorf("OnCallAt() = %s on %q from %s (override %v), want %s on %q from %s (override %v)",
+					shift.User.ID, shift.Layer, shift.Start, shift.Override, tt.wantUser, tt.wantLayer, tt.wantStart, tt.wantOverride)
+			}
+		})
+	}
+
+	if shift := s.OnCallAt(at(1, 1, 10, 0)); shift.User.Name != "Alice" {
+		t.Errorf("OnCallAt() user name = %s, want Alice", shift.User.Name)
+	}
+	if shift := s.OnCallAt(at(1, 8, 10, 0)); shift.User.Name != "bob" {
+		t.Errorf("OnCallAt() unknown user name = %s, want bob", shift.User.Name)
+	}
+}
+
+func TestShifts(t *testing.T) {
+	s := newTestSchedule(t)
+
+	want := []struct {
+		user       string
+		start, end time.Time
+	}{
+		{"carol", at(1, 3, 0, 0), at(1, 3, 6, 0)},
+		{"alice", at(1, 3, 6, 0), at(1, 3, 12, 0)},
+		{"dave", at(1, 3, 12, 0), at(1, 3, 14, 0)},
+		{"alice", at(1, 3, 14, 0), at(1, 3, 22, 0)},
+		{"carol", at(1, 3, 22, 0), at(1, 4, 0, 0)},
+	}
+
+	shifts := s.Shifts(at(1, 3, 0, 0), at(1, 4, 0, 0))
+	if len(shifts) != len(want) {
+		t.Fatalf("Shifts() returned %d shifts, want %d: %v", len(shifts), len(want), shifts)
+	}
+	for i, w := range want {
+		if shifts[i].User.ID != w.user || !shifts[i].Start.Equal(w.start) || !shifts[i].End.Equal(w.end) {
+			t.Errorf("shift %d = %s from %s to %s, want %s from %s to %s", i, shifts[i].User.ID, shifts[i].Start, shifts[i].End, w.user, w.start, w.end)
+		}
+	}
+
+	// Shifts of the same user are merged across handoffs
+	merged := s.Shifts(at(1, 1, 9, 0), at(1, 1, 21, 0))
+	if len(merged) != 1 || merged[0].User.ID != "alice" {
+		t.Errorf("Shifts() = %v, want one shift of alice", merged)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	layer := config.ScheduleLayerConfig{Start: "2024-01-01", Users: []string{"alice"}}
+	withLayer := func(change func(l *config.ScheduleLayerConfig)) config.ScheduleConfig {
+		l := layer
+		change(&l)
+		return config.ScheduleConfig{Name: "payments", Layers: []config.ScheduleLayerConfig{l}}
+	}
+
+	tests := []struct {
+		name string
+		def  config.ScheduleConfig
+	}{
+		{"no name", config.ScheduleConfig{Layers: []config.ScheduleLayerConfig{layer}}},
+		{"no layers", config.ScheduleConfig{Name: "payments"}},
+		{"invalid timezone", config.ScheduleConfig{Name: "payments", Timezone: "Mars/Olympus", Layers: []config.ScheduleLayerConfig{layer}}},
+		{"no users", withLayer(func(l *config.ScheduleLayerConfig) { l.Users = nil })},
+		{"monthly rotation", withLayer(func(l *config.ScheduleLayerConfig) { l.Rotation = "monthly" })},
+		{"no start", withLayer(func(l *config.ScheduleLayerConfig) { l.Start = "" })},
+		{"invalid handoff time", withLayer(func(l *config.ScheduleLayerConfig) { l.HandoffTime = "9am" })},
+		{"invalid handoff day", withLayer(func(l *config.ScheduleLayerConfig) { l.HandoffDay = "someday" })},
+		{"override ending before it starts", config.ScheduleConfig{Name: "payments", Layers: []config.ScheduleLayerConfig{layer}, Overrides: []config.ScheduleOverrideConfig{
+			{User: "bob", Start: "2024-01-03T14:00", End: "2024-01-03T12:00"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(tt.def, nil); err == nil {
+			t.Errorf("New() with %s succeeded", tt.name)
+		}
+	}
+}
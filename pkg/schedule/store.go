@@ -0,0 +1,271 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// Redis hash holding the schedules created or changed through the API
+	redisSchedulesKey = "oncall_schedules"
+
+	// Schedules are read again from Redis at most this often, so the changes made
+	// through the API of another replica apply here too
+	refreshInterval = 5 * time.Second
+)
+
+// Store keeps the schedules defined in config and through the API
+type Store struct {
+	mu          sync.RWMutex
+	users       map[string]*User
+	schedules   map[string]*Schedule
+	configured  map[string]*Schedule
+	loadedAt    time.Time
+	redisClient *redis.Client
+}
+
+// Global instance for singleton access
+var (
+	store     *Store
+	storeOnce sync.Once
+)
+
+// NewStore creates a store from the configured users and schedules.
+// When a Redis client is given, API changes are persisted and shared between replicas.
+func NewStore(cfg config.OnCallSchedulesConfig, redisClient *redis.Client) (*Store, error) {
+	s := &Store{
+		users:       make(map[string]*User),
+		schedules:   make(map[string]*Schedule),
+		configured:  make(map[string]*Schedule),
+		redisClient: redisClient,
+	}
+
+	for _, u := range cfg.Users {
+		name := u.Name
+		if name == "" {
+			name = u.ID
+		}
+		s.users[u.ID] = &User{
+			ID:          u.ID,
+			Name:        name,
+			Email:       u.Email,
+			SlackUserID: u.SlackUserID,
+			Phone:       u.Phone,
+		}
+	}
+
+	for _, def := range cfg.Schedules {
+		sched, err := New(def, s.users)
+		if err != nil {
+			return nil, err
+		}
+		s.schedules[sched.Name] = sched
+		s.configured[sched.Name] = sched
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.loadedAt = time.Now()
+
+	return s, nil
+}
+
+// InitStore initializes the global singleton instance
+func InitStore(cfg config.OnCallSchedulesConfig, redisClient *redis.Client) error {
+	var err error
+
+	storeOnce.Do(func() {
+		store, err = NewStore(cfg, redisClient)
+		if err == nil {
+			log.Printf("On-call schedules initialized with %d schedules", len(store.schedules))
+		}
+	})
+
+	return err
+}
+
+// GetStore returns the global singleton instance, or nil when on-call schedules are disabled
+func GetStore() *Store {
+	return store
+}
+
+// load reads the schedules saved through the API, they take precedence over config
+func (s *Store) load() error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	items, err := s.redisClient.HGetAll(context.Background(), redisSchedulesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load schedules from Redis: %w", err)
+	}
+
+	schedules := make(map[string]*Schedule, len(s.configured)+len(items))
+	for name, sched := range s.configured {
+		schedules[name] = sched
+	}
+
+	for name, data := range items {
+		var def config.ScheduleConfig
+		if err := json.Unmarshal([]byte(data), &def); err != nil {
+			log.Printf("Skipping invalid schedule '%s' stored in Redis: %v", name, err)
+			continue
+		}
+
+		sched, err := New(def, s.users)
+		if err != nil {
+			log.Printf("Skipping invalid schedule '%s' stored in Redis: %v", name, err)
+			continue
+		}
+		schedules[sched.Name] = sched
+	}
+
+	s.mu.Lock()
+	s.schedules = schedules
+	s.mu.Unlock()
+
+	return nil
+}
+
+// refresh loads the schedules again when they are older than refreshInterval.
+// The schedules in memory are kept while Redis is unavailable.
+func (s *Store) refresh() {
+	if s.redisClient == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if time.Since(s.loadedAt) < refreshInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		log.Printf("Failed to refresh on-call schedules: %v", err)
+	}
+}
+
+// List returns all schedules sorted by name
+func (s *Store) List() []*Schedule {
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		list = append(list, sched)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns a schedule by name
+func (s *Store) Get(name string) (*Schedule, bool) {
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sched, ok := s.schedules[name]
+	return sched, ok
+}
+
+// User returns a configured user by ID
+func (s *Store) User(id string) (*User, bool) {
+	u, ok := s.users[id]
+	return u, ok
+}
+
+// OnCall returns who is on call for a schedule at t
+func (s *Store) OnCall(name string, t time.Time) (*Shift, error) {
+	sched, ok := s.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("schedule '%s' not found", name)
+	}
+
+	return sched.OnCallAt(t), nil
+}
+
+// Put creates or replaces a schedule
+func (s *Store) Put(def config.ScheduleConfig) (*Schedule, error) {
+	sched, err := New(def, s.users)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.persist(def); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.schedules[sched.Name] = sched
+	s.mu.Unlock()
+
+	return sched, nil
+}
+
+// AddOverride adds an override to an existing schedule
+func (s *Store) AddOverride(name string, oc config.ScheduleOverrideConfig) (*Schedule, error) {
+	sched, ok := s.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("schedule '%s' not found", name)
+	}
+
+	def := sched.Definition()
+	def.Overrides = append(append([]config.ScheduleOverrideConfig(nil), def.Overrides...), oc)
+
+	return s.Put(def)
+}
+
+// Delete removes a schedule created through the API
+func (s *Store) Delete(name string) error {
+	if _, ok := s.Get(name); !ok {
+		return fmt.Errorf("schedule '%s' not found", name)
+	}
+
+	if _, ok := s.configured[name]; ok {
+		return fmt.Errorf("schedule '%s' is defined in the config file and cannot be deleted", name)
+	}
+
+	if s.redisClient != nil {
+		if err := s.redisClient.HDel(context.Background(), redisSchedulesKey, name).Err(); err != nil {
+			return fmt.Errorf("failed to delete schedule '%s' from Redis: %w", name, err)
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.schedules, name)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) persist(def config.ScheduleConfig) error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule '%s': %w", def.Name, err)
+	}
+
+	if err := s.redisClient.HSet(context.Background(), redisSchedulesKey, def.Name, data).Err(); err != nil {
+		return fmt.Errorf("failed to store schedule '%s' in Redis: %w", def.Name, err)
+	}
+
+	return nil
+}
@@ -144,7 +144,8 @@ func (s *Scheduler) createJobFunc(job config.ScheduledJob) func() {
 			return
 		}
 
-		// Add scheduled metadata
+		// Add scheduled metadata, the source tells routes and silences apart from Alertmanager
+		payload["source"] = "scheduled"
 		payload["scheduled_job"] = job.Name
 		payload["scheduled_time"] = time.Now().Format(time.RFC3339)
 
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/VersusControl/versus-incident/pkg/store"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Templates of the message posted when an incident is auto-resolved, email is sent as HTML
+const (
+	autoResolvedTemplate      = "config/auto_resolved.tmpl"
+	autoResolvedEmailTemplate = "config/auto_resolved_email.tmpl"
+)
+
+var autoResolvedTemplates = map[string]string{
+	"slack":    autoResolvedTemplate,
+	"telegram": autoResolvedTemplate,
+	"viber":    autoResolvedTemplate,
+	"email":    autoResolvedEmailTemplate,
+	"msteams":  autoResolvedTemplate,
+	"lark":     autoResolvedTemplate,
+}
+
+// StartAutoResolver resolves the firing incidents past their auto-resolve deadline every interval.
+// It returns a function that stops it.
+func StartAutoResolver(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				autoResolve(now)
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+func autoResolve(now time.Time) {
+	incidents := store.GetIncidentStore()
+	if incidents == nil {
+		return
+	}
+
+	active, err := incidents.Active()
+	if err != nil {
+		log.Printf("Failed to list the active incidents to auto-resolve: %v", err)
+		return
+	}
+
+	for _, incident := range active {
+		if incident.AutoResolveAt == nil || now.Before(*incident.AutoResolveAt) || incident.Content == nil {
+			continue
+		}
+
+		// Another instance may have resolved it, or a new notification replaced it
+		resolved, err := incidents.ResolveActive(incident)
+		if err != nil {
+			log.Printf("Failed to auto-resolve incident %s: %v", incident.ID, err)
+			continue
+		}
+		if !resolved {
+			continue
+		}
+
+		log.Printf("Incident %s auto-resolved, no new notification since %s", incident.ID, incident.CreatedAt.Format(time.RFC3339))
+
+		if err := resolveSilentIncident(incident); err != nil {
+			log.Printf("Failed to close auto-resolved incident %s: %v", incident.ID, err)
+		}
+	}
+}
+
+// resolveSilentIncident cancels the escalation of an auto-resolved incident, closes it on the on-call
+// providers, and posts a message to the routes that ask for it
+func resolveSilentIncident(incident *m.Incident) error {
+	payload := routing.NewPayload(incident.TeamID, *incident.Content)
+	deliveries := route(payload, nil, true)
+
+	resolved := *incident
+	resolved.Resolved = true
+
+	var errs []error
+
+	if cfg, _ := escalationConfig(deliveries); cfg.OnCall.Enable {
+		if workflow, err := onCallWorkflow(); err != nil {
+			errs = append(errs, err)
+		} else {
+			workflow.Resolve(&resolved)
+		}
+	}
+
+	for _, d := range deliveries {
+		if d.muted || d.route.AutoResolve == nil || !d.route.AutoResolve.Notify {
+			continue
+		}
+
+		content := map[string]interface{}{
+			"Title":      incidentTitle(incident, payload),
+			"IncidentID": incident.ID,
+			"Route":      d.route.Key(),
+			"After":      d.route.AutoResolve.After,
+			"Since":      incident.CreatedAt.Format(time.RFC3339),
+			"Content":    *incident.Content,
+		}
+
+		message := m.NewIncident(incident.TeamID, &content, true)
+		if err := notifyWithTemplates(d, autoResolvedTemplates, message); err != nil {
+			errs = append(errs, fmt.Errorf("route '%s': %w", d.route.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
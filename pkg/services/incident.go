@@ -1,38 +1,123 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"log"
+	"slices"
+	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/common"
 	"github.com/VersusControl/versus-incident/pkg/config"
 	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/grouping"
+	"github.com/VersusControl/versus-incident/pkg/resolve"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/VersusControl/versus-incident/pkg/schedule"
+	"github.com/VersusControl/versus-incident/pkg/silence"
+	"github.com/VersusControl/versus-incident/pkg/sources"
+	"github.com/VersusControl/versus-incident/pkg/store"
+	"github.com/VersusControl/versus-incident/pkg/storm"
 
 	m "github.com/VersusControl/versus-incident/pkg/models"
 )
 
 func CreateIncident(teamID string, content *map[string]interface{}, params ...*map[string]string) error {
-	var cfg *config.Config
+	return createIncidents(nil, teamID, content, params...)
+}
+
+// CreateSourceIncident creates an incident from the payload of a known source, e.g. Datadog.
+// The source and the labels the adapter extracts are added to the payload when it has none,
+// so routes and silences can match them like the fields of the payload.
+func CreateSourceIncident(adapter core.SourceAdapter, teamID string, content *map[string]interface{}, params ...*map[string]string) error {
+	enriched := make(map[string]interface{}, len(*content)+2)
+	for k, v := range *content {
+		enriched[k] = v
+	}
+
+	if _, ok := enriched["source"]; !ok {
+		enriched["source"] = adapter.Name()
+	}
+	if _, ok := enriched["labels"]; !ok {
+		if labels := adapter.Normalize(*content).Labels; len(labels) > 0 {
+			values := make(map[string]interface{}, len(labels))
+			for k, v := range labels {
+				values[k] = v
+			}
+			enriched["labels"] = values
+		}
+	}
 
+	return createIncidents(adapter, teamID, &enriched, params...)
+}
+
+func createIncidents(adapter core.SourceAdapter, teamID string, content *map[string]interface{}, params ...*map[string]string) error {
+	var overwrite *map[string]string
 	if len(params) > 0 {
-		cfg = config.GetConfigWitParamsOverwrite(params[0])
-	} else {
-		cfg = config.GetConfig()
+		overwrite = params[0]
 	}
 
-	// Initialization of providers and alert
-	factory := common.NewAlertProviderFactory(cfg)
-	providers, err := factory.CreateProviders()
-	if err != nil {
-		return fmt.Errorf("failed to create providers: %v", err)
+	// Alertmanager and Grafana send groups of alerts, each alert can be its own incident
+	if payloads := splitAlerts(teamID, *content, overwrite); payloads != nil {
+		var errs []error
+		for i := range payloads {
+			if err := createIncident(adapter, teamID, &payloads[i], overwrite); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
 	}
 
-	alert := core.NewAlert(providers...)
+	return createIncident(adapter, teamID, content, overwrite)
+}
+
+func createIncident(adapter core.SourceAdapter, teamID string, content *map[string]interface{}, overwrite *map[string]string) error {
+	if adapter == nil {
+		adapter = sources.Detect(*content)
+	}
+
+	payload := routing.NewPayload(teamID, *content)
 
 	// Skip AckURL and On-Call if resolved alert
-	resolved := isResolved(*content)
+	resolved := isResolved(payload)
 
 	incident := m.NewIncident(teamID, content, resolved)
+	incident.Fingerprint = fingerprint(adapter, *content)
+	sources.Normalize(incident, adapter)
+
+	// Silenced and inhibited incidents are recorded, but neither notified nor escalated.
+	// A resolved one still closes the page of its alert, which may have fired before the silence.
+	if reason := suppressedReason(incident, payload); reason != "" {
+		incident.SuppressedReason = reason
+		log.Printf("Incident %s is not notified: %s", incident.ID, reason)
+
+		recordIncident(incident)
+
+		if resolved {
+			if cfg, _ := escalationConfig(route(payload, overwrite, resolved)); cfg.OnCall.Enable {
+				return resolveOnCall(incident)
+			}
+		}
+		return nil
+	}
+
+	deliveries := route(payload, overwrite, resolved)
+
+	cfg, escalation := escalationConfig(deliveries)
+
+	// Sources that never resolve their alerts get the deadline of the first matching route that sets one
+	if !resolved {
+		for _, d := range deliveries {
+			if d.route.AutoResolve != nil {
+				deadline := d.route.AutoResolve.Deadline(incident.CreatedAt)
+				incident.AutoResolveAt = &deadline
+				break
+			}
+		}
+	}
 
 	// Dereference the Pointer and add AckURL if needed
 	contentClone := make(map[string]interface{})
@@ -47,34 +132,410 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 		incident.Content = &contentClone
 	}
 
-	if err := alert.SendAlert(incident); err != nil {
-		return err
+	// Expose the normalized fields to templates next to the raw payload, e.g. {{ .Incident.Title }}
+	contentClone["Incident"] = incident.Normalized
+	incident.Content = &contentClone
+
+	// Expose the team to templates, e.g. {{ .TeamID }}
+	if teamID != "" {
+		contentClone["TeamID"] = teamID
+
+		incident.Content = &contentClone
 	}
 
-	if !resolved && cfg.OnCall.Enable {
-		workflow := core.GetOnCallWorkflow()
-		if err := workflow.Start(incident.ID, cfg.OnCall); err != nil {
-			return err
+	// Expose who is on call to templates, e.g. {{ .OnCall.Name }}
+	if onCall := currentOnCall(cfg); onCall != nil {
+		contentClone["OnCall"] = onCall
+
+		incident.Content = &contentClone
+	}
+
+	recordIncident(incident)
+
+	// Each matching route notifies its own providers and channels, a route that fails
+	// doesn't keep the others nor on-call from being notified
+	var errs []error
+	escalate := true
+	for i, d := range deliveries {
+		if d.muted {
+			continue
+		}
+
+		// During an alert storm the incident is only listed in the next digest of the route,
+		// and isn't escalated one by one either
+		if d.route.Storm != nil && holdForDigest(d, incident, payload) {
+			if i == escalation {
+				escalate = false
+			}
+			continue
+		}
+
+		// Grouped routes notify and escalate once per group, when the group is first notified after
+		// group_wait. Later incidents are thread updates.
+		if d.route.Group != nil {
+			if grouper := grouping.GetGrouper(); grouper != nil {
+				escalateGroup := i == escalation && escalate
+				grouper.Add(d.route.GroupKey(payload), d.route.Group, incident, func(grouped *m.Incident, update bool) error {
+					err := notify(d, grouped)
+					if escalateGroup && !update && !grouped.Resolved && cfg.OnCall.Enable {
+						err = errors.Join(err, startOnCall(grouped, cfg, d.params))
+					}
+					return err
+				})
+				if i == escalation {
+					escalate = false
+				}
+				continue
+			}
+		}
+
+		if err := notify(d, incident); err != nil {
+			errs = append(errs, fmt.Errorf("route '%s': %w", d.route.Name, err))
+		}
+	}
+
+	if !resolved && cfg.OnCall.Enable && escalate {
+		if err := startOnCall(incident, cfg, deliveries[escalation].params); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
+	// Close the paged incident on providers that support it
+	if resolved && cfg.OnCall.Enable {
+		if err := resolveOnCall(incident); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolveOnCall cancels the escalation of the resolved alert and closes its page on the providers
+func resolveOnCall(incident *m.Incident) error {
+	workflow, err := onCallWorkflow()
+	if err != nil {
+		return err
+	}
+	workflow.Resolve(incident)
 	return nil
 }
 
-// isResolved checks if the alert is resolved by checking common status fields
-func isResolved(content map[string]interface{}) bool {
-	// List of common field names that might indicate status
-	statusFields := []string{"status", "state", "alertState"}
+// startOnCall escalates the incident with the on-call config and parameters of the escalation route
+func startOnCall(incident *m.Incident, cfg *config.Config, params map[string]string) error {
+	workflow, err := onCallWorkflow()
+	if err != nil {
+		return err
+	}
+	return workflow.Start(incident, cfg.OnCall, params)
+}
 
-	for _, field := range statusFields {
-		if val, ok := content[field]; ok {
-			if strVal, isString := val.(string); isString {
-				// Case-insensitive check for "resolved"
-				return strings.EqualFold(strVal, "resolved")
+// onCallWorkflow returns the on-call workflow, which isn't initialized when neither the config
+// nor a route enables on-call, e.g. for ?oncall_enable=true
+func onCallWorkflow() (*core.OnCallWorkflow, error) {
+	workflow := core.GetOnCallWorkflow()
+	if workflow == nil {
+		return nil, fmt.Errorf("on-call is not initialized, set oncall.enable or oncall.initialized_only")
+	}
+	return workflow, nil
+}
+
+// delivery is the config and alert providers of one matching route
+type delivery struct {
+	route     *routing.Route
+	params    map[string]string
+	cfg       *config.Config
+	providers []string
+	muted     bool // Quiet hours that also mute the alert notifications
+}
+
+// route matches the alert against the routing tree and builds a config per matching route.
+// Query parameters of the request take precedence over the settings of the route, but not over
+// its quiet hours. Quiet hours only keep on-call from starting, a resolved alert still closes its page.
+func route(payload *routing.Payload, overwrite *map[string]string, resolved bool) []delivery {
+	matched := []*routing.Route{{Name: "default"}}
+	if router := routing.GetRouter(); router != nil {
+		matched = router.Match(payload)
+	}
+
+	var deliveries []delivery
+	for _, r := range matched {
+		params := r.Params()
+
+		if overwrite != nil {
+			for k, v := range *overwrite {
+				params[k] = v
 			}
 		}
+
+		// Quiet hours win over ?oncall_enable=true, e.g. of a shared integration URL
+		quiet := r.QuietHours.Active(time.Now(), payload)
+		if quiet && !resolved {
+			log.Printf("Quiet hours of route '%s', on-call is skipped", r.Name)
+			params["oncall_enable"] = "false"
+		}
+
+		// Keep the global config when there is nothing to change
+		cfg := config.GetConfig()
+		if len(params) > 0 || len(r.Templates) > 0 {
+			cfg = config.GetConfigWitParamsOverwrite(&params)
+			r.ApplyTemplates(cfg)
+		}
+
+		deliveries = append(deliveries, delivery{
+			route:     r,
+			params:    params,
+			cfg:       cfg,
+			providers: r.Providers,
+			muted:     quiet && r.QuietHours.MuteNotifications,
+		})
 	}
 
-	// Not resolved (trigger On-Call)
+	return deliveries
+}
+
+// escalationConfig returns the config of the first matching route with on-call enabled and its index,
+// the escalation policy comes from it
+func escalationConfig(deliveries []delivery) (*config.Config, int) {
+	for i, d := range deliveries {
+		if d.cfg.OnCall.Enable {
+			return d.cfg, i
+		}
+	}
+	return deliveries[0].cfg, 0
+}
+
+// notify sends the incident to the providers and channels of the route
+func notify(d delivery, incident *m.Incident) error {
+	// Initialization of providers and alert
+	factory := common.NewAlertProviderFactory(d.cfg).WithProviders(d.providers)
+	providers, err := factory.CreateProviders()
+	if err != nil {
+		return fmt.Errorf("failed to create providers: %v", err)
+	}
+
+	alert := core.NewAlert(providers...)
+
+	return alert.SendAlert(incident)
+}
+
+// holdForDigest counts the incident for the storm detector of the route, and reports
+// whether the route is storming, so the incident is sent with the next digest instead
+func holdForDigest(d delivery, incident *m.Incident, payload *routing.Payload) bool {
+	detector := storm.GetDetector()
+	if detector == nil {
+		return false
+	}
+
+	status := "firing"
+	if incident.Resolved {
+		status = "resolved"
+	}
+
+	item := storm.Item{
+		ID:       incident.ID,
+		Title:    incidentTitle(incident, payload),
+		Source:   incident.Source,
+		Severity: incident.Severity,
+		Status:   status,
+		Time:     incident.CreatedAt.Format(time.RFC3339),
+	}
+
+	return detector.Observe(d.route.Key(), d.route.Storm, item, digestSender(d))
+}
+
+// digestSender sends the storm digests of a route with its digest templates
+func digestSender(d delivery) storm.Sender {
+	return func(digest *m.Incident) error {
+		return notifyWithTemplates(d, d.route.Storm.Templates, digest)
+	}
+}
+
+// notifyWithTemplates sends a notification to the providers and channels of the route with other templates
+func notifyWithTemplates(d delivery, templates map[string]string, incident *m.Incident) error {
+	cfg := config.GetConfigWitParamsOverwrite(&d.params)
+	d.route.ApplyTemplates(cfg)
+	routing.SetTemplates(cfg, templates)
+
+	d.cfg = cfg
+	return notify(d, incident)
+}
+
+// incidentTitle returns a short name of the alert for digests
+func incidentTitle(incident *m.Incident, payload *routing.Payload) string {
+	if incident.Title != "" {
+		return incident.Title
+	}
+	for _, field := range []string{"alertname", "AlarmName", "title", "ServiceName", "message"} {
+		if v := payload.Value(field); v != "" {
+			return v
+		}
+	}
+	return incident.ID
+}
+
+// suppressedReason returns why the incident must not be notified, or "" when it must
+func suppressedReason(incident *m.Incident, payload *routing.Payload) string {
+	if silences := silence.GetStore(); silences != nil {
+		if reason := silences.Muted(time.Now(), payload); reason != "" {
+			return reason
+		}
+	}
+
+	if inhibitor := silence.GetInhibitor(); inhibitor != nil {
+		reason, err := inhibitor.Inhibited(incident, payload)
+		if err != nil {
+			log.Printf("Failed to check inhibit rules for incident %s: %v", incident.ID, err)
+		}
+		return reason
+	}
+
+	return ""
+}
+
+// recordIncident keeps the incident in the incident store, failures don't stop the notifications
+func recordIncident(incident *m.Incident) {
+	incidents := store.GetIncidentStore()
+	if incidents == nil {
+		return
+	}
+
+	if err := incidents.Save(incident); err != nil {
+		log.Printf("Failed to record incident %s: %v", incident.ID, err)
+	}
+}
+
+// currentOnCall returns the person on call for the default schedule, or nil when there is none
+func currentOnCall(cfg *config.Config) map[string]interface{} {
+	store := schedule.GetStore()
+	if store == nil || cfg.OnCallSchedules.Default == "" {
+		return nil
+	}
+
+	shift, err := store.OnCall(cfg.OnCallSchedules.Default, time.Now())
+	if err != nil || shift == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"ID":          shift.User.ID,
+		"Name":        shift.User.Name,
+		"Email":       shift.User.Email,
+		"SlackUserID": shift.User.SlackUserID,
+		"Phone":       shift.User.Phone,
+		"Schedule":    shift.Schedule,
+		"ShiftEnd":    shift.End.Format(time.RFC3339),
+	}
+}
+
+// splitAlerts returns one payload per alert of an Alertmanager or Grafana payload, or nil to
+// keep the group as one incident. The split_alerts query parameter decides, otherwise the
+// split_alerts setting of the matching routes.
+func splitAlerts(teamID string, content map[string]interface{}, overwrite *map[string]string) []map[string]interface{} {
+	alerts, ok := content["alerts"].([]interface{})
+	if !ok || len(alerts) == 0 || !shouldSplit(teamID, content, overwrite) {
+		return nil
+	}
+
+	payloads := make([]map[string]interface{}, 0, len(alerts))
+	for _, a := range alerts {
+		alert, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Keep the shape of the group, so templates and routing work the same
+		payload := make(map[string]interface{}, len(content))
+		for k, v := range content {
+			payload[k] = v
+		}
+		payload["alerts"] = []interface{}{alert}
+
+		if status, ok := alert["status"].(string); ok {
+			payload["status"] = status
+		}
+		if labels, ok := alert["labels"].(map[string]interface{}); ok {
+			payload["commonLabels"] = labels
+		}
+		if annotations, ok := alert["annotations"].(map[string]interface{}); ok {
+			payload["commonAnnotations"] = annotations
+		}
+
+		if fp, ok := alert["fingerprint"].(string); ok && fp != "" {
+			payload["fingerprint"] = fp
+		} else {
+			payload["fingerprint"] = fingerprint(nil, map[string]interface{}{"labels": alert["labels"]})
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads
+}
+
+func shouldSplit(teamID string, content map[string]interface{}, overwrite *map[string]string) bool {
+	if overwrite != nil {
+		if v, ok := (*overwrite)["split_alerts"]; ok {
+			return v == "true"
+		}
+	}
+
+	router := routing.GetRouter()
+	if router == nil {
+		return false
+	}
+
+	for _, r := range router.Match(routing.NewPayload(teamID, content)) {
+		if r.Split {
+			return true
+		}
+	}
 	return false
 }
+
+// isResolved checks if the alert is resolved with the configured resolve rules
+func isResolved(payload *routing.Payload) bool {
+	return resolve.GetResolver().Resolved(payload)
+}
+
+// fingerprint returns a key that stays the same between the firing and the resolved
+// notification of an alert, so on-call providers can deduplicate and close it
+func fingerprint(adapter core.SourceAdapter, content map[string]interface{}) string {
+	// Prefer identifiers that the alert source already provides
+	if f, ok := adapter.(core.Fingerprinter); ok {
+		if fp := f.Fingerprint(content); fp != "" {
+			return fp
+		}
+	}
+
+	keyFields := []string{"fingerprint", "groupKey", "alias", "dedup_key", "incident_key", "AlarmName"}
+
+	for _, field := range keyFields {
+		if val, ok := content[field]; ok {
+			if strVal, isString := val.(string); isString && strVal != "" {
+				return strVal
+			}
+		}
+	}
+
+	// Otherwise hash the payload without the fields that change on resolve
+	changing := resolve.GetResolver().Fields()
+	stable := make(map[string]interface{}, len(content))
+	for k, v := range content {
+		switch k {
+		case "status", "state", "alertState", "AckURL":
+			continue
+		}
+		if slices.Contains(changing, k) {
+			continue
+		}
+		stable[k] = v
+	}
+
+	data, err := json.Marshal(stable) // Map keys are sorted, so the output is deterministic
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/sources"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		firing   map[string]interface{}
+		resolved map[string]interface{}
+		same     bool
+	}{
+		{
+			name:     "key field",
+			firing:   map[string]interface{}{"fingerprint": "abc", "status": "firing"},
+			resolved: map[string]interface{}{"fingerprint": "abc", "status": "resolved", "endsAt": "now"},
+			same:     true,
+		},
+		{
+			name:     "hash without the resolve fields",
+			firing:   map[string]interface{}{"service": "api", "status": "firing", "AckURL": "https://ack/1"},
+			resolved: map[string]interface{}{"service": "api", "status": "resolved", "AckURL": "https://ack/2"},
+			same:     true,
+		},
+		{
+			name:     "hash of different alerts",
+			firing:   map[string]interface{}{"service": "api", "status": "firing"},
+			resolved: map[string]interface{}{"service": "worker", "status": "resolved"},
+		},
+		{
+			name:     "datadog monitor and scope",
+			firing:   map[string]interface{}{"alert_id": "42", "alert_scope": "host:web-1", "alert_transition": "Triggered", "body": "CPU 95%"},
+			resolved: map[string]interface{}{"alert_id": "42", "alert_scope": "host:web-1", "alert_transition": "Recovered", "body": "CPU 40%"},
+			same:     true,
+		},
+		{
+			name:     "datadog scopes of a multi alert",
+			firing:   map[string]interface{}{"alert_id": "42", "alert_scope": "host:web-1", "alert_transition": "Triggered"},
+			resolved: map[string]interface{}{"alert_id": "42", "alert_scope": "host:web-2", "alert_transition": "Recovered"},
+		},
+		{
+			name: "azure alert ID",
+			firing: map[string]interface{}{"schemaId": "azureMonitorCommonAlertSchema", "data": map[string]interface{}{
+				"essentials": map[string]interface{}{"alertId": "/alerts/1", "monitorCondition": "Fired"},
+			}},
+			resolved: map[string]interface{}{"schemaId": "azureMonitorCommonAlertSchema", "data": map[string]interface{}{
+				"essentials": map[string]interface{}{"alertId": "/alerts/1", "monitorCondition": "Resolved", "resolvedDateTime": "now"},
+			}},
+			same: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firing := fingerprint(sources.Detect(tt.firing), tt.firing)
+			resolved := fingerprint(sources.Detect(tt.resolved), tt.resolved)
+
+			if firing == "" || resolved == "" {
+				t.Fatalf("fingerprint() = %q, %q, want fingerprints", firing, resolved)
+			}
+			if (firing == resolved) != tt.same {
+				t.Errorf("fingerprint() = %q, %q, want same %v", firing, resolved, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package silence
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+	st "github.com/VersusControl/versus-incident/pkg/store"
+)
+
+// InhibitRule suppresses the target incidents while a source incident is firing
+type InhibitRule struct {
+	Name           string
+	SourceMatchers []*routing.Matcher
+	TargetMatchers []*routing.Matcher
+	Equal          []string
+}
+
+// Inhibitor checks new incidents against the firing ones in the incident store
+type Inhibitor struct {
+	rules     []*InhibitRule
+	incidents *st.IncidentStore
+}
+
+// Global instance for singleton access
+var (
+	inhibitor     *Inhibitor
+	inhibitorOnce sync.Once
+)
+
+// NewInhibitor creates an inhibitor from the configured rules
+func NewInhibitor(rules []config.InhibitRuleConfig, incidents *st.IncidentStore) (*Inhibitor, error) {
+	inh := &Inhibitor{incidents: incidents}
+
+	for i, rc := range rules {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+
+		if len(rc.SourceMatchers) == 0 || len(rc.TargetMatchers) == 0 {
+			return nil, fmt.Errorf("inhibit rule '%s': source_matchers and target_matchers are required", name)
+		}
+
+		source, err := routing.ParseMatchers(rc.SourceMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule '%s': %w", name, err)
+		}
+
+		target, err := routing.ParseMatchers(rc.TargetMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule '%s': %w", name, err)
+		}
+
+		inh.rules = append(inh.rules, &InhibitRule{
+			Name:           name,
+			SourceMatchers: source,
+			TargetMatchers: target,
+			Equal:          rc.Equal,
+		})
+	}
+
+	return inh, nil
+}
+
+// InitInhibitor initializes the global singleton instance
+func InitInhibitor(rules []config.InhibitRuleConfig, incidents *st.IncidentStore) error {
+	var err error
+
+	inhibitorOnce.Do(func() {
+		inhibitor, err = NewInhibitor(rules, incidents)
+		if err == nil {
+			log.Printf("Inhibition initialized with %d rules", len(rules))
+		}
+	})
+
+	return err
+}
+
+// GetInhibitor returns the global singleton instance, or nil when there are no inhibit rules
+func GetInhibitor() *Inhibitor {
+	return inhibitor
+}
+
+// Inhibited returns why the incident is inhibited by a firing incident, or "" when it isn't
+func (inh *Inhibitor) Inhibited(incident *m.Incident, p *routing.Payload) (string, error) {
+	var targeted []*InhibitRule
+	for _, rule := range inh.rules {
+		if routing.MatchAll(rule.TargetMatchers, p) {
+			targeted = append(targeted, rule)
+		}
+	}
+
+	if len(targeted) == 0 {
+		return "", nil
+	}
+
+	active, err := inh.incidents.Active()
+	if err != nil {
+		return "", err
+	}
+
+	for _, source := range active {
+		// An incident doesn't inhibit itself, e.g. a repeated notification of the same alert
+		if source.Fingerprint == incident.Fingerprint || source.Content == nil {
+			continue
+		}
+
+		sp := routing.NewPayload(source.TeamID, *source.Content)
+
+		for _, rule := range targeted {
+			if routing.MatchAll(rule.SourceMatchers, sp) && equal(rule.Equal, sp, p) {
+				return fmt.Sprintf("inhibited by %s (rule %s)", source.ID, rule.Name), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func equal(fields []string, a, b *routing.Payload) bool {
+	for _, field := range fields {
+		if a.Value(field) != b.Value(field) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package silence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/robfig/cron/v3"
+)
+
+// States of a silence
+const (
+	StatePending = "pending"
+	StateActive  = "active"
+	StateExpired = "expired"
+)
+
+// Silence mutes the matching incidents between StartsAt and EndsAt
+type Silence struct {
+	ID        string    `json:"id"`
+	Matchers  []string  `json:"matchers"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+
+	matchers []*routing.Matcher
+}
+
+// parse validates the silence and parses its matchers
+func (s *Silence) parse() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher is required")
+	}
+
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	matchers, err := routing.ParseMatchers(s.Matchers)
+	if err != nil {
+		return err
+	}
+	s.matchers = matchers
+
+	return nil
+}
+
+// State returns whether the silence is pending, active or expired at t
+func (s *Silence) State(t time.Time) string {
+	switch {
+	case t.Before(s.StartsAt):
+		return StatePending
+	case t.Before(s.EndsAt):
+		return StateActive
+	default:
+		return StateExpired
+	}
+}
+
+// Mutes reports whether the silence is active at t and matches the payload
+func (s *Silence) Mutes(t time.Time, p *routing.Payload) bool {
+	return s.State(t) == StateActive && routing.MatchAll(s.matchers, p)
+}
+
+// MaintenanceWindow mutes the matching incidents on a recurring schedule
+type MaintenanceWindow struct {
+	config.MaintenanceWindowConfig
+
+	schedule cron.Schedule
+	duration time.Duration
+	location *time.Location
+	matchers []*routing.Matcher
+}
+
+// NewMaintenanceWindow parses a maintenance window from the config
+func NewMaintenanceWindow(mc config.MaintenanceWindowConfig) (*MaintenanceWindow, error) {
+	w := &MaintenanceWindow{MaintenanceWindowConfig: mc, location: time.Local}
+
+	schedule, err := cron.ParseStandard(mc.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window '%s': invalid schedule '%s': %w", mc.Name, mc.Schedule, err)
+	}
+	w.schedule = schedule
+
+	duration, err := time.ParseDuration(mc.Duration)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("maintenance window '%s': invalid duration '%s'", mc.Name, mc.Duration)
+	}
+	w.duration = duration
+
+	if mc.Timezone != "" {
+		location, err := time.LoadLocation(mc.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window '%s': invalid timezone '%s': %w", mc.Name, mc.Timezone, err)
+		}
+		w.location = location
+	}
+
+	matchers, err := routing.ParseMatchers(mc.Matchers)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance window '%s': %w", mc.Name, err)
+	}
+	w.matchers = matchers
+
+	return w, nil
+}
+
+// Current returns the start and end of the window containing t, if any
+func (w *MaintenanceWindow) Current(t time.Time) (time.Time, time.Time, bool) {
+	// The window contains t when it started during the last duration
+	start := w.schedule.Next(t.In(w.location).Add(-w.duration))
+	if start.After(t) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, start.Add(w.duration), true
+}
+
+// Mutes reports whether the window is open at t and matches the payload
+func (w *MaintenanceWindow) Mutes(t time.Time, p *routing.Payload) bool {
+	_, _, open := w.Current(t)
+	return open && routing.MatchAll(w.matchers, p)
+}
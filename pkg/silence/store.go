@@ -0,0 +1,281 @@
+package silence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/routing"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	// Redis hash holding the silences created through the API
+	redisSilencesKey = "silences"
+
+	// Expired silences are still listed for a day
+	expiredRetention = 24 * time.Hour
+
+	// Silences are read again from Redis at most this often, so the silences created
+	// through the API of another replica apply here too
+	refreshInterval = 5 * time.Second
+)
+
+// Store keeps the silences and the configured maintenance windows
+type Store struct {
+	mu          sync.RWMutex
+	silences    map[string]*Silence
+	windows     []*MaintenanceWindow
+	loadedAt    time.Time
+	redisClient *redis.Client
+}
+
+// Global instance for singleton access
+var (
+	store     *Store
+	storeOnce sync.Once
+)
+
+// NewStore creates a store with the configured maintenance windows.
+// When a Redis client is given, silences are persisted and shared between replicas.
+func NewStore(cfg config.SilencesConfig, redisClient *redis.Client) (*Store, error) {
+	s := &Store{
+		silences:    make(map[string]*Silence),
+		redisClient: redisClient,
+	}
+
+	for _, mc := range cfg.MaintenanceWindows {
+		w, err := NewMaintenanceWindow(mc)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.loadedAt = time.Now()
+
+	return s, nil
+}
+
+// InitStore initializes the global singleton instance
+func InitStore(cfg config.SilencesConfig, redisClient *redis.Client) error {
+	var err error
+
+	storeOnce.Do(func() {
+		store, err = NewStore(cfg, redisClient)
+		if err == nil {
+			log.Printf("Silences initialized with %d maintenance windows", len(store.windows))
+		}
+	})
+
+	return err
+}
+
+// GetStore returns the global singleton instance, or nil when silences are disabled
+func GetStore() *Store {
+	return store
+}
+
+// load replaces the silences with the ones stored in Redis
+func (s *Store) load() error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	items, err := s.redisClient.HGetAll(context.Background(), redisSilencesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load silences from Redis: %w", err)
+	}
+
+	silences := make(map[string]*Silence, len(items))
+	for id, data := range items {
+		var silence Silence
+		if err := json.Unmarshal([]byte(data), &silence); err != nil {
+			log.Printf("Skipping invalid silence '%s' stored in Redis: %v", id, err)
+			continue
+		}
+
+		if err := silence.parse(); err != nil {
+			log.Printf("Skipping invalid silence '%s' stored in Redis: %v", id, err)
+			continue
+		}
+		silences[id] = &silence
+	}
+
+	s.mu.Lock()
+	s.silences = silences
+	s.mu.Unlock()
+
+	return nil
+}
+
+// refresh loads the silences again when they are older than refreshInterval.
+// The silences in memory are kept while Redis is unavailable.
+func (s *Store) refresh() {
+	if s.redisClient == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if time.Since(s.loadedAt) < refreshInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		log.Printf("Failed to refresh silences: %v", err)
+	}
+}
+
+// Create validates and stores a new silence
+func (s *Store) Create(silence *Silence) error {
+	now := time.Now()
+
+	silence.ID = uuid.NewString()
+	silence.CreatedAt = now
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+
+	if err := silence.parse(); err != nil {
+		return err
+	}
+
+	if err := s.persist(silence); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.silences[silence.ID] = silence
+	s.mu.Unlock()
+
+	s.prune(now)
+
+	return nil
+}
+
+// Expire ends a silence now
+func (s *Store) Expire(id string) (*Silence, error) {
+	s.refresh()
+
+	s.mu.RLock()
+	existing, ok := s.silences[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("silence '%s' not found", id)
+	}
+
+	now := time.Now()
+	if existing.State(now) == StateExpired {
+		return existing, nil
+	}
+
+	expired := *existing
+	expired.EndsAt = now
+	if expired.StartsAt.After(now) {
+		expired.StartsAt = now
+	}
+
+	if err := s.persist(&expired); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.silences[id] = &expired
+	s.mu.Unlock()
+
+	return &expired, nil
+}
+
+// List returns the silences, newest first
+func (s *Store) List() []*Silence {
+	s.refresh()
+	s.prune(time.Now())
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		list = append(list, silence)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list
+}
+
+// MaintenanceWindows returns the configured maintenance windows
+func (s *Store) MaintenanceWindows() []*MaintenanceWindow {
+	return s.windows
+}
+
+// Muted returns why the payload is muted at t, or "" when it isn't
+func (s *Store) Muted(t time.Time, p *routing.Payload) string {
+	s.refresh()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, silence := range s.silences {
+		if silence.Mutes(t, p) {
+			return fmt.Sprintf("silenced by %s (%s)", silence.ID, silence.Comment)
+		}
+	}
+
+	for _, w := range s.windows {
+		if w.Mutes(t, p) {
+			return fmt.Sprintf("maintenance window %s", w.Name)
+		}
+	}
+
+	return ""
+}
+
+// prune removes the silences that expired more than a day ago
+func (s *Store) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, silence := range s.silences {
+		if now.Sub(silence.EndsAt) < expiredRetention {
+			continue
+		}
+
+		if s.redisClient != nil {
+			if err := s.redisClient.HDel(context.Background(), redisSilencesKey, id).Err(); err != nil {
+				log.Printf("Failed to delete silence '%s' from Redis: %v", id, err)
+				continue
+			}
+		}
+		delete(s.silences, id)
+	}
+}
+
+func (s *Store) persist(silence *Silence) error {
+	if s.redisClient == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal silence: %w", err)
+	}
+
+	if err := s.redisClient.HSet(context.Background(), redisSilencesKey, silence.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed to store silence in Redis: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"fmt"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Alertmanager normalizes the webhook notifications of Prometheus Alertmanager
+type Alertmanager struct{}
+
+func (a *Alertmanager) Name() string {
+	return "alertmanager"
+}
+
+func (a *Alertmanager) Detect(content map[string]interface{}) bool {
+	return content["alerts"] != nil && content["groupKey"] != nil
+}
+
+func (a *Alertmanager) Normalize(content map[string]interface{}) m.Normalized {
+	n := normalizeAlerts(content)
+	if n.Title == "" {
+		n.Title = "Prometheus Alert"
+	}
+	n.Links = links(map[string]string{
+		"source":       field(content, "alerts.0.generatorURL"),
+		"runbook":      runbook(content),
+		"alertmanager": field(content, "externalURL"),
+	})
+	return n
+}
+
+// normalizeAlerts reads the fields shared by the alerts of an Alertmanager group, which
+// Grafana alerting sends as well. A group of several alerts is titled with their count.
+func normalizeAlerts(content map[string]interface{}) m.Normalized {
+	alerts := list(content, "alerts")
+
+	lbls := object(content, "commonLabels")
+	if len(lbls) == 0 {
+		lbls = object(content, "alerts.0.labels")
+	}
+	annotations := object(content, "commonAnnotations")
+	if len(annotations) == 0 {
+		annotations = object(content, "alerts.0.annotations")
+	}
+
+	n := m.Normalized{
+		Title:       first(lbls, "alertname"),
+		Severity:    Severity(field(lbls, "severity")),
+		Resource:    first(lbls, "instance", "pod", "job", "host"),
+		Description: first(annotations, "description", "message", "summary"),
+		Labels:      labels(lbls),
+	}
+
+	if n.Title == "" {
+		n.Title = first(annotations, "summary", "title")
+	}
+	if len(alerts) > 1 {
+		n.Title = fmt.Sprintf("%s (%d alerts)", n.Title, len(alerts))
+	}
+
+	var instances []string
+	for _, alert := range alerts {
+		if obj, ok := alert.(map[string]interface{}); ok {
+			if n.Resource == "" {
+				instances = append(instances, first(obj, "labels.instance", "labels.pod", "labels.job", "labels.host"))
+			}
+
+			// The group started with its oldest alert
+			if t := parseTime(field(obj, "startsAt")); t != nil && (n.StartsAt == nil || t.Before(*n.StartsAt)) {
+				n.StartsAt = t
+			}
+		}
+	}
+	if n.Resource == "" {
+		n.Resource = join(instances)
+	}
+
+	return n
+}
+
+// runbook returns the runbook annotation of the group or of its first alert
+func runbook(content map[string]interface{}) string {
+	return first(content, "commonAnnotations.runbook_url", "alerts.0.annotations.runbook_url")
+}
@@ -0,0 +1,145 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Azure normalizes the action group webhooks of Azure Monitor with the common alert schema
+type Azure struct{}
+
+// Sev0 is the most severe, Sev4 is verbose
+var azureSeverities = map[string]string{
+	"sev0": "CRITICAL",
+	"sev1": "ERROR",
+	"sev2": "WARNING",
+	"sev3": "INFO",
+	"sev4": "INFO",
+}
+
+func (a *Azure) Name() string {
+	return "azure"
+}
+
+func (a *Azure) Detect(content map[string]interface{}) bool {
+	return field(content, "schemaId") == "azureMonitorCommonAlertSchema" || object(content, "data.essentials") != nil
+}
+
+func (a *Azure) Normalize(content map[string]interface{}) m.Normalized {
+	essentials := object(content, "data.essentials")
+
+	n := m.Normalized{
+		Title:       first(essentials, "alertRule"),
+		Severity:    azureSeverities[strings.ToLower(field(essentials, "severity"))],
+		Description: first(essentials, "description"),
+		StartsAt:    parseTime(field(essentials, "firedDateTime")),
+		Labels: map[string]string{
+			"alert_rule":         field(essentials, "alertRule"),
+			"signal_type":        field(essentials, "signalType"),
+			"monitoring_service": field(essentials, "monitoringService"),
+		},
+	}
+
+	if n.Description == "" {
+		n.Description = azureCondition(content)
+	}
+
+	var names []string
+	for i, id := range list(essentials, "alertTargetIDs") {
+		target := parseAzureResourceID(scalar(id))
+		names = append(names, target["resource"])
+
+		// Labels are about the first target, alerts rarely have more
+		if i == 0 {
+			for k, v := range target {
+				n.Labels[k] = v
+			}
+		}
+	}
+	if len(names) == 0 {
+		for _, item := range list(essentials, "configurationItems") {
+			names = append(names, scalar(item))
+		}
+	}
+	n.Resource = join(names)
+
+	for k, v := range n.Labels {
+		if v == "" {
+			delete(n.Labels, k)
+		}
+	}
+
+	if id := field(essentials, "alertId"); id != "" {
+		n.Links = map[string]string{
+			"console": "https://portal.azure.com/#blade/Microsoft_Azure_Monitoring_Alerts/AlertDetailsTemplateBlade/alertId/" + url.QueryEscape(id),
+		}
+	}
+
+	return n
+}
+
+// Fingerprint is the alert ID, the same in the fired and the resolved notifications
+func (a *Azure) Fingerprint(content map[string]interface{}) string {
+	return field(content, "data.essentials.alertId")
+}
+
+// azureCondition describes the first condition of a metric alert, e.g. "Percentage CPU GreaterThan 80 (value: 92.5)"
+func azureCondition(content map[string]interface{}) string {
+	condition := object(content, "data.alertContext.condition.allOf.0")
+	if condition == nil {
+		return ""
+	}
+
+	metric := first(condition, "metricName", "searchQuery")
+	if metric == "" {
+		return ""
+	}
+
+	description := strings.TrimSpace(fmt.Sprintf("%s %s %s", metric, field(condition, "operator"), field(condition, "threshold")))
+	if value := field(condition, "metricValue"); value != "" {
+		description += fmt.Sprintf(" (value: %s)", value)
+	}
+	return description
+}
+
+// parseAzureResourceID splits a resource ID into the labels subscription, resource_group, resource_type
+// and resource, e.g. /subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachines/<name>
+func parseAzureResourceID(id string) map[string]string {
+	labels := make(map[string]string)
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+
+	for i := 0; i+1 < len(parts); i += 2 {
+		key, value := strings.ToLower(parts[i]), parts[i+1]
+
+		switch key {
+		case "subscriptions":
+			labels["subscription"] = value
+		case "resourcegroups":
+			labels["resource_group"] = value
+		case "providers":
+			// providers/<namespace>/<type>/<name>[/<type>/<name>]...
+			rest := parts[i+1:]
+			if len(rest) >= 3 {
+				types := []string{rest[0]}
+				for j := 1; j+1 < len(rest); j += 2 {
+					types = append(types, rest[j])
+				}
+				labels["resource_type"] = strings.Join(types, "/")
+				labels["resource"] = rest[len(rest)-1]
+			}
+			return labels
+		}
+	}
+
+	// Alerts on a resource group or a subscription
+	if group, ok := labels["resource_group"]; ok {
+		labels["resource"] = group
+	} else if sub, ok := labels["subscription"]; ok {
+		labels["resource"] = sub
+	}
+
+	return labels
+}
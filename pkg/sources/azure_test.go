@@ -0,0 +1,92 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAzureNormalize(t *testing.T) {
+	vm := "/subscriptions/sub-1/resourceGroups/rg-prod/providers/Microsoft.Compute/virtualMachines/vm-1"
+
+	content := map[string]interface{}{
+		"schemaId": "azureMonitorCommonAlertSchema",
+		"data": map[string]interface{}{
+			"essentials": map[string]interface{}{
+				"alertId":           "/subscriptions/sub-1/providers/Microsoft.AlertsManagement/alerts/abc",
+				"alertRule":         "High CPU",
+				"severity":          "Sev1",
+				"signalType":        "Metric",
+				"monitorCondition":  "Fired",
+				"monitoringService": "Platform",
+				"alertTargetIDs":    []interface{}{vm},
+				"firedDateTime":     "2024-05-01T10:20:30.123Z",
+			},
+			"alertContext": map[string]interface{}{
+				"condition": map[string]interface{}{
+					"allOf": []interface{}{
+						map[string]interface{}{"metricName": "Percentage CPU", "operator": "GreaterThan", "threshold": "80", "metricValue": 92.5},
+					},
+				},
+			},
+		},
+	}
+
+	a := &Azure{}
+	if got := Detect(content).Name(); got != "azure" {
+		t.Fatalf("detected source %s, want azure", got)
+	}
+
+	n := a.Normalize(content)
+	if n.Title != "High CPU" || n.Severity != "ERROR" || n.Resource != "vm-1" || n.Description != "Percentage CPU GreaterThan 80 (value: 92.5)" {
+		t.Errorf("Normalize() = %+v", n)
+	}
+	if n.StartsAt == nil || n.StartsAt.Year() != 2024 {
+		t.Errorf("Normalize() starts at %v", n.StartsAt)
+	}
+
+	wantLabels := map[string]string{
+		"alert_rule":         "High CPU",
+		"signal_type":        "Metric",
+		"monitoring_service": "Platform",
+		"subscription":       "sub-1",
+		"resource_group":     "rg-prod",
+		"resource_type":      "Microsoft.Compute/virtualMachines",
+		"resource":           "vm-1",
+	}
+	if !reflect.DeepEqual(n.Labels, wantLabels) {
+		t.Errorf("Normalize() labels = %v, want %v", n.Labels, wantLabels)
+	}
+	if n.Links["console"] == "" {
+		t.Error("Normalize() has no console link")
+	}
+	if got := a.Fingerprint(content); got != "/subscriptions/sub-1/providers/Microsoft.AlertsManagement/alerts/abc" {
+		t.Errorf("Fingerprint() = %q", got)
+	}
+}
+
+func TestParseAzureResourceID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want map[string]string
+	}{
+		{
+			"/subscriptions/sub-1/resourceGroups/rg-prod/providers/Microsoft.Sql/servers/sql-1/databases/orders",
+			map[string]string{"subscription": "sub-1", "resource_group": "rg-prod", "resource_type": "Microsoft.Sql/servers/databases", "resource": "orders"},
+		},
+		{
+			"/subscriptions/sub-1/resourcegroups/rg-prod",
+			map[string]string{"subscription": "sub-1", "resource_group": "rg-prod", "resource": "rg-prod"},
+		},
+		{
+			"/subscriptions/sub-1",
+			map[string]string{"subscription": "sub-1", "resource": "sub-1"},
+		},
+		{"", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		if got := parseAzureResourceID(tt.id); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAzureResourceID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
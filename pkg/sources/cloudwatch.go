@@ -0,0 +1,83 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// CloudWatch normalizes the alarm notifications of AWS CloudWatch, as delivered by SNS
+type CloudWatch struct{}
+
+func (c *CloudWatch) Name() string {
+	return "cloudwatch"
+}
+
+func (c *CloudWatch) Detect(content map[string]interface{}) bool {
+	return content["AlarmName"] != nil
+}
+
+func (c *CloudWatch) Normalize(content map[string]interface{}) m.Normalized {
+	name := field(content, "AlarmName")
+	namespace := first(content, "Trigger.Namespace")
+	metric := first(content, "Trigger.MetricName")
+
+	// arn:aws:cloudwatch:<region>:<account>:alarm:<name>
+	region := "us-east-1"
+	if arn := strings.Split(field(content, "AlarmArn"), ":"); len(arn) > 3 && arn[3] != "" {
+		region = arn[3]
+	}
+
+	n := m.Normalized{
+		Title:       name,
+		Description: field(content, "NewStateReason"),
+		StartsAt:    parseTime(field(content, "StateChangeTime")),
+		Labels: map[string]string{
+			"aws_account": field(content, "AWSAccountId"),
+			"aws_region":  region,
+		},
+		Links: links(map[string]string{
+			"console": fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%s#alarmsV2:alarm/%s",
+				region, region, url.PathEscape(name)),
+		}),
+	}
+
+	switch field(content, "NewStateValue") {
+	case "ALARM":
+		n.Severity = "CRITICAL"
+	case "INSUFFICIENT_DATA":
+		n.Severity = "WARNING"
+	}
+
+	if namespace == "" {
+		namespace = "AWS"
+	}
+	n.Resource = namespace + "/" + metric
+	if metric != "" {
+		n.Labels["metric"] = namespace + "/" + metric
+	}
+
+	var dimensions []string
+	for _, d := range list(content, "Trigger.Dimensions") {
+		if obj, ok := d.(map[string]interface{}); ok {
+			name, value := field(obj, "name"), field(obj, "value")
+			if name != "" && value != "" {
+				dimensions = append(dimensions, name+": "+value)
+				n.Labels[name] = value
+			}
+		}
+	}
+	if len(dimensions) > 0 {
+		n.Resource += " (" + strings.Join(dimensions, ", ") + ")"
+	}
+
+	for k, v := range n.Labels {
+		if v == "" {
+			delete(n.Labels, k)
+		}
+	}
+
+	return n
+}
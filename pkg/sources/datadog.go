@@ -0,0 +1,118 @@
+package sources
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Datadog normalizes the webhook notifications of Datadog monitors, with the payload:
+//
+//	{
+//	  "alert_id": "$ALERT_ID",
+//	  "alert_transition": "$ALERT_TRANSITION",
+//	  "alert_priority": "$ALERT_PRIORITY",
+//	  "alert_scope": "$ALERT_SCOPE",
+//	  "title": "$EVENT_TITLE",
+//	  "body": "$EVENT_MSG",
+//	  "tags": "$TAGS",
+//	  "link": "$LINK",
+//	  "date": "$DATE"
+//	}
+type Datadog struct{}
+
+// The transition prefixes the event title, e.g. "[Triggered on {host:web-1}] High CPU"
+var datadogTitlePrefix = regexp.MustCompile(`^\[[^\]]*\]\s*`)
+
+func (d *Datadog) Name() string {
+	return "datadog"
+}
+
+func (d *Datadog) Detect(content map[string]interface{}) bool {
+	return content["alert_transition"] != nil
+}
+
+func (d *Datadog) Normalize(content map[string]interface{}) m.Normalized {
+	n := m.Normalized{
+		Title:       datadogTitlePrefix.ReplaceAllString(first(content, "title", "event_title"), ""),
+		Severity:    Severity(first(content, "alert_priority", "priority")),
+		Resource:    first(content, "hostname", "alert_scope", "scope"),
+		Description: strings.TrimSpace(strings.ReplaceAll(first(content, "body", "event_msg"), "%%%", "")),
+		StartsAt:    datadogTime(first(content, "date", "last_updated")),
+		Labels:      d.Labels(content),
+		Links: links(map[string]string{
+			"source": first(content, "link", "url"),
+		}),
+	}
+
+	// Monitors without priority tell the type of the alert
+	if n.Severity == "" {
+		switch field(content, "alert_type") {
+		case "error":
+			n.Severity = "ERROR"
+		case "warning":
+			n.Severity = "WARNING"
+		}
+	}
+
+	return n
+}
+
+// Fingerprint is the monitor and the group it alerts on, a multi alert monitor
+// has one alert per scope, e.g. "host:web-1"
+func (d *Datadog) Fingerprint(content map[string]interface{}) string {
+	id := field(content, "alert_id")
+	if id == "" {
+		return ""
+	}
+	return "datadog:" + id + ":" + first(content, "alert_scope", "scope")
+}
+
+// Labels parses the tags and the scope of the alert, e.g. "env:prod,service:api".
+// Tags without a value are labels set to "true".
+func (d *Datadog) Labels(content map[string]interface{}) map[string]string {
+	var tags []string
+
+	for _, path := range []string{"tags", "alert_scope"} {
+		switch v := lookup(content, path).(type) {
+		case string:
+			tags = append(tags, strings.Split(v, ",")...)
+		case []interface{}:
+			for _, tag := range v {
+				tags = append(tags, scalar(tag))
+			}
+		}
+	}
+
+	result := make(map[string]string)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		if k, v, ok := strings.Cut(tag, ":"); ok {
+			result[k] = v
+		} else {
+			result[tag] = "true"
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// datadogTime reads $DATE and $LAST_UPDATED, in milliseconds since the epoch
+func datadogTime(value string) *time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return parseTime(value)
+	}
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
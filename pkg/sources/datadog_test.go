@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDatadogNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content map[string]interface{}
+		want    map[string]string // Title, Severity, Resource, Description
+		labels  map[string]string
+		starts  *time.Time
+	}{
+		{
+			name: "triggered",
+			content: map[string]interface{}{
+				"alert_id":         "42",
+				"alert_transition": "Triggered",
+				"alert_priority":   "P1",
+				"alert_scope":      "host:web-1",
+				"title":            "[Triggered on {host:web-1}] High CPU",
+				"body":             "%%%\nCPU is above 90%\n%%%",
+				"tags":             "env:prod,service:api,canary",
+				"date":             "1714558800000",
+			},
+			want:   map[string]string{"Title": "High CPU", "Severity": "CRITICAL", "Resource": "host:web-1", "Description": "CPU is above 90%"},
+			labels: map[string]string{"env": "prod", "service": "api", "canary": "true", "host": "web-1"},
+			starts: func() *time.Time { t := time.Date(2024, 5, 1, 10, 20, 0, 0, time.UTC); return &t }(),
+		},
+		{
+			name: "warning without priority",
+			content: map[string]interface{}{
+				"alert_transition": "Warn",
+				"alert_type":       "warning",
+				"title":            "Disk filling up",
+				"tags":             []interface{}{"env:staging", "*"},
+			},
+			want:   map[string]string{"Title": "Disk filling up", "Severity": "WARNING"},
+			labels: map[string]string{"env": "staging"},
+		},
+	}
+
+	d := &Datadog{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !d.Detect(tt.content) {
+				t.Fatal("Detect() = false")
+			}
+			if got := Detect(tt.content).Name(); got != "datadog" {
+				t.Errorf("detected source %s, want datadog", got)
+			}
+
+			n := d.Normalize(tt.content)
+			got := map[string]string{"Title": n.Title, "Severity": n.Severity, "Resource": n.Resource, "Description": n.Description}
+			for k, v := range got {
+				if v == "" {
+					delete(got, k)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(n.Labels, tt.labels) {
+				t.Errorf("Normalize() labels = %v, want %v", n.Labels, tt.labels)
+			}
+			if (n.StartsAt == nil) != (tt.starts == nil) || (n.StartsAt != nil && !n.StartsAt.Equal(*tt.starts)) {
+				t.Errorf("Normalize() starts at %v, want %v", n.StartsAt, tt.starts)
+			}
+		})
+	}
+}
+
+func TestDatadogFingerprint(t *testing.T) {
+	tests := []struct {
+		content map[string]interface{}
+		want    string
+	}{
+		{map[string]interface{}{"alert_id": "42", "alert_scope": "host:web-1"}, "datadog:42:host:web-1"},
+		{map[string]interface{}{"alert_id": "42"}, "datadog:42:"},
+		{map[string]interface{}{"alert_scope": "host:web-1"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := (&Datadog{}).Fingerprint(tt.content); got != tt.want {
+			t.Errorf("Fingerprint(%v) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"sort"
+	"strings"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Email normalizes the emails of the SMTP listener
+type Email struct{}
+
+func (e *Email) Name() string {
+	return "email"
+}
+
+func (e *Email) Detect(content map[string]interface{}) bool {
+	return field(content, "source") == "email" && content["subject"] != nil
+}
+
+// Normalize reads the severity from the labels, so an extraction rule can set it from the subject
+func (e *Email) Normalize(content map[string]interface{}) m.Normalized {
+	n := m.Normalized{
+		Title:       field(content, "subject"),
+		Resource:    field(content, "from"),
+		Description: field(content, "body"),
+		StartsAt:    parseTime(field(content, "timestamp")),
+		Labels:      labels(object(content, "labels")),
+	}
+
+	n.Severity = Severity(n.Labels["severity"])
+
+	return n
+}
+
+// Fingerprint is the sender and the extracted labels, so the email that reports the recovery
+// of a job resolves the incident of its failure. Emails without extracted labels are unique.
+func (e *Email) Fingerprint(content map[string]interface{}) string {
+	var keys []string
+	for k, v := range labels(object(content, "labels")) {
+		switch k {
+		case "from", "severity", "status":
+			continue
+		}
+		keys = append(keys, k+"="+v)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+
+	sort.Strings(keys)
+	return "email:" + field(content, "from") + ":" + strings.Join(keys, ",")
+}
@@ -0,0 +1,75 @@
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// FluentBit normalizes the log records forwarded by Fluent Bit, with their Kubernetes metadata
+type FluentBit struct{}
+
+var (
+	criticalLog = regexp.MustCompile(`(?i)\b(CRITICAL|FATAL|PANIC)\b`)
+	errorLog    = regexp.MustCompile(`(?i)\bERROR\b`)
+	warningLog  = regexp.MustCompile(`(?i)\bWARN(ING)?\b`)
+)
+
+func (f *FluentBit) Name() string {
+	return "fluentbit"
+}
+
+func (f *FluentBit) Detect(content map[string]interface{}) bool {
+	return content["log"] != nil || field(content, "kubernetes.pod_name") != ""
+}
+
+func (f *FluentBit) Normalize(content map[string]interface{}) m.Normalized {
+	log := field(content, "log")
+	app := first(content, "kubernetes.labels.app", "kubernetes.container_name")
+	if app == "" {
+		app = "unknown"
+	}
+
+	n := m.Normalized{
+		Title:       fmt.Sprintf("Error in %s", app),
+		Severity:    Severity(field(content, "level")),
+		Description: strings.TrimSpace(log),
+		StartsAt:    parseTime(first(content, "time", "@timestamp", "date")),
+		Labels:      labels(object(content, "kubernetes.labels")),
+	}
+
+	if n.Severity == "" {
+		switch {
+		case criticalLog.MatchString(log):
+			n.Severity = "CRITICAL"
+		case errorLog.MatchString(log):
+			n.Severity = "ERROR"
+		case warningLog.MatchString(log):
+			n.Severity = "WARNING"
+		}
+	}
+
+	if pod := field(content, "kubernetes.pod_name"); pod != "" {
+		namespace := field(content, "kubernetes.namespace_name")
+		container := field(content, "kubernetes.container_name")
+		n.Resource = fmt.Sprintf("pod/%s (container: %s) in namespace %s", pod, container, namespace)
+
+		if n.Labels == nil {
+			n.Labels = make(map[string]string)
+		}
+		for k, v := range map[string]string{
+			"namespace": namespace,
+			"pod":       pod,
+			"container": container,
+			"node":      field(content, "kubernetes.host"),
+		} {
+			if v != "" {
+				n.Labels[k] = v
+			}
+		}
+	}
+
+	return n
+}
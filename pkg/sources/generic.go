@@ -0,0 +1,38 @@
+package sources
+
+import (
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Generic normalizes any JSON payload from the fields most tools name alike
+type Generic struct{}
+
+func (g *Generic) Name() string {
+	return "generic"
+}
+
+func (g *Generic) Detect(content map[string]interface{}) bool {
+	return true
+}
+
+func (g *Generic) Normalize(content map[string]interface{}) m.Normalized {
+	n := m.Normalized{
+		Title:       first(content, "title", "alertname", "name", "ServiceName", "summary", "message"),
+		Severity:    Severity(first(content, "severity", "level", "priority", "labels.severity")),
+		Resource:    first(content, "resource", "host", "hostname", "instance", "service", "ServiceName"),
+		Description: first(content, "description", "message", "Logs", "log", "text"),
+		StartsAt:    parseTime(first(content, "startsAt", "timestamp", "time", "date")),
+		Links: links(map[string]string{
+			"source":  first(content, "url", "link"),
+			"runbook": first(content, "runbook_url", "runbook"),
+		}),
+	}
+
+	lbls := object(content, "labels")
+	if lbls == nil {
+		lbls = object(content, "tags")
+	}
+	n.Labels = labels(lbls)
+
+	return n
+}
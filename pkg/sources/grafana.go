@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"regexp"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Grafana normalizes the contact point webhooks of Grafana alerting, unified and legacy
+type Grafana struct{}
+
+var (
+	// valueString lists the expressions of the rule, e.g. "[ var='B' labels={instance=a} value=92.5 ], [ var='C' ... value=1 ]"
+	grafanaValue     = regexp.MustCompile(`\[([^\[\]]*)\]`)
+	grafanaValueName = regexp.MustCompile(`(?:var|metric)='([^']*)'`)
+	grafanaValueNum  = regexp.MustCompile(`value=([^\s,\]]+)`)
+)
+
+func (g *Grafana) Name() string {
+	return "grafana"
+}
+
+func (g *Grafana) Detect(content map[string]interface{}) bool {
+	unified := content["alerts"] != nil && content["orgId"] != nil
+	legacy := content["ruleName"] != nil && content["evalMatches"] != nil
+	return unified || legacy
+}
+
+func (g *Grafana) Normalize(content map[string]interface{}) m.Normalized {
+	// Legacy alerting sends one rule per notification
+	if content["alerts"] == nil {
+		n := m.Normalized{
+			Title:       first(content, "ruleName", "title"),
+			Severity:    Severity(first(content, "tags.severity")),
+			Description: first(content, "message"),
+			Labels:      labels(object(content, "tags")),
+			Links: links(map[string]string{
+				"source": field(content, "ruleUrl"),
+				"image":  field(content, "imageUrl"),
+			}),
+		}
+
+		values := make(map[string]string)
+		for _, match := range list(content, "evalMatches") {
+			if obj, ok := match.(map[string]interface{}); ok {
+				values[field(obj, "metric")] = field(obj, "value")
+			}
+		}
+		if len(values) > 0 {
+			n.Values = values
+		}
+
+		return n
+	}
+
+	n := normalizeAlerts(content)
+	if n.Title == "" {
+		n.Title = "Grafana Alert"
+	}
+	n.Links = links(map[string]string{
+		"source":    field(content, "alerts.0.generatorURL"),
+		"runbook":   runbook(content),
+		"dashboard": field(content, "alerts.0.dashboardURL"),
+		"panel":     field(content, "alerts.0.panelURL"),
+		"silence":   field(content, "alerts.0.silenceURL"),
+		"image":     field(content, "alerts.0.imageURL"),
+	})
+
+	// Recent versions send the values as an object, all of them send the valueString
+	n.Values = labels(object(content, "alerts.0.values"))
+	if n.Values == nil {
+		n.Values = parseValueString(field(content, "alerts.0.valueString"))
+	}
+
+	return n
+}
+
+// parseValueString reads the expressions and their values from a valueString
+func parseValueString(s string) map[string]string {
+	values := make(map[string]string)
+
+	for _, group := range grafanaValue.FindAllStringSubmatch(s, -1) {
+		value := grafanaValueNum.FindStringSubmatch(group[1])
+		if value == nil {
+			continue
+		}
+
+		name := "value"
+		if n := grafanaValueName.FindStringSubmatch(group[1]); n != nil {
+			name = n[1]
+		}
+		values[name] = value[1]
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,60 @@
+package sources
+
+import (
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Sentry normalizes the issue and event webhooks of Sentry
+type Sentry struct{}
+
+func (s *Sentry) Name() string {
+	return "sentry"
+}
+
+func (s *Sentry) Detect(content map[string]interface{}) bool {
+	if content["project_slug"] != nil {
+		return true
+	}
+	if content["action"] != nil && content["data"] != nil && content["actor"] != nil {
+		return true
+	}
+	return field(content, "event.event_id") != "" || field(content, "data.issue.id") != ""
+}
+
+func (s *Sentry) Normalize(content map[string]interface{}) m.Normalized {
+	project := first(content, "project_slug", "data.issue.project.slug", "project")
+	culprit := first(content, "data.issue.culprit", "culprit", "event.culprit")
+
+	n := m.Normalized{
+		Title:       first(content, "data.issue.title", "message", "event.title"),
+		Severity:    Severity(first(content, "data.issue.level", "event.level", "level")),
+		Description: first(content, "data.issue.metadata.value", "event.logentry.formatted", "event.message"),
+		StartsAt:    parseTime(first(content, "data.issue.firstSeen", "event.timestamp", "event.datetime")),
+		Links: links(map[string]string{
+			"source": first(content, "data.issue.web_url", "url", "event.web_url"),
+		}),
+	}
+
+	if project == "" {
+		project = "unknown"
+	}
+	n.Resource = project
+	if culprit != "" {
+		n.Resource += "/" + culprit
+	}
+
+	// Event tags are [key, value] pairs
+	n.Labels = map[string]string{"project": project}
+	for _, tag := range list(content, "event.tags") {
+		if pair, ok := tag.([]interface{}); ok && len(pair) == 2 {
+			if k, ok := pair[0].(string); ok {
+				n.Labels[k] = scalar(pair[1])
+			}
+		}
+	}
+	if env := first(content, "event.environment", "environment"); env != "" {
+		n.Labels["environment"] = env
+	}
+
+	return n
+}
@@ -0,0 +1,229 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Adapters of the known sources, in the order they are detected. The generic adapter
+// detects every payload, so it must stay the last.
+var adapters = []core.SourceAdapter{
+	&Grafana{},
+	&Alertmanager{},
+	&CloudWatch{},
+	&Sentry{},
+	&Datadog{},
+	&Azure{},
+	&Syslog{},
+	&Email{},
+	&FluentBit{},
+	&Generic{},
+}
+
+// Detect returns the adapter of the source that sent the payload
+func Detect(content map[string]interface{}) core.SourceAdapter {
+	for _, adapter := range adapters {
+		if adapter.Detect(content) {
+			return adapter
+		}
+	}
+	return &Generic{}
+}
+
+// Get returns the adapter with the name, or nil when there is none
+func Get(name string) core.SourceAdapter {
+	for _, adapter := range adapters {
+		if adapter.Name() == name {
+			return adapter
+		}
+	}
+	return nil
+}
+
+// Normalize fills the normalized fields of the incident from its payload, with the adapter
+// of its source. The adapter is detected from the payload when it is nil.
+func Normalize(incident *m.Incident, adapter core.SourceAdapter) {
+	if incident.Content == nil {
+		return
+	}
+
+	if adapter == nil {
+		adapter = Detect(*incident.Content)
+	}
+
+	n := adapter.Normalize(*incident.Content)
+	n.Source = adapter.Name()
+
+	// The resolve rules decide the status, so it is the same for every source
+	n.Status = "FIRING"
+	if incident.Resolved {
+		n.Status = "RESOLVED"
+	}
+
+	if n.Severity == "" {
+		n.Severity = "INFO"
+	}
+	if n.Title == "" {
+		n.Title = "Unknown Alert"
+	}
+
+	incident.Normalized = n
+}
+
+// Severity maps the severities and priorities of the sources to CRITICAL, ERROR, WARNING or INFO
+func Severity(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "critical", "fatal", "alarm", "emergency", "p1", "1":
+		return "CRITICAL"
+	case "error", "high", "major", "p2", "2":
+		return "ERROR"
+	case "warning", "warn", "medium", "minor", "p3", "3":
+		return "WARNING"
+	case "":
+		return ""
+	}
+	return "INFO"
+}
+
+// lookup returns the value at a path of the payload, e.g. "data.issue.title" or "alerts.0.labels"
+func lookup(content map[string]interface{}, path string) interface{} {
+	var current interface{} = content
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			current = v[i]
+		default:
+			return nil
+		}
+	}
+
+	return current
+}
+
+// field returns the value at a path of the payload as a string, or "" when it isn't a scalar
+func field(content map[string]interface{}, path string) string {
+	return scalar(lookup(content, path))
+}
+
+// scalar returns a JSON value as a string, or "" when it isn't a scalar
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// first returns the first path of the payload with a value
+func first(content map[string]interface{}, paths ...string) string {
+	for _, path := range paths {
+		if v := field(content, path); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// object returns the object at a path of the payload, or nil
+func object(content map[string]interface{}, path string) map[string]interface{} {
+	v, _ := lookup(content, path).(map[string]interface{})
+	return v
+}
+
+// list returns the array at a path of the payload, or nil
+func list(content map[string]interface{}, path string) []interface{} {
+	v, _ := lookup(content, path).([]interface{})
+	return v
+}
+
+// labels converts the scalar values of an object to labels
+func labels(obj map[string]interface{}) map[string]string {
+	if len(obj) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(obj))
+	for k, value := range obj {
+		if v := scalar(value); v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// links drops the links without URL
+func links(named map[string]string) map[string]string {
+	for name, url := range named {
+		if url == "" {
+			delete(named, name)
+		}
+	}
+	if len(named) == 0 {
+		return nil
+	}
+	return named
+}
+
+// join lists the distinct values, sorted
+func join(values []string) string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	sort.Strings(distinct)
+	return strings.Join(distinct, ", ")
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000-0700", // CloudWatch
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+}
+
+// parseTime reads the timestamps of the sources: RFC 3339 and the like, or Unix seconds.
+// It returns nil for missing and zero timestamps.
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			if t.IsZero() || t.Year() <= 1 {
+				return nil
+			}
+			return &t
+		}
+	}
+
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+		t := time.Unix(0, int64(secs*float64(time.Second))).UTC()
+		return &t
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"fmt"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Syslog normalizes the messages of the syslog listener
+type Syslog struct{}
+
+var syslogSeverities = map[string]string{
+	"emerg":   "CRITICAL",
+	"alert":   "CRITICAL",
+	"crit":    "CRITICAL",
+	"err":     "ERROR",
+	"warning": "WARNING",
+}
+
+func (s *Syslog) Name() string {
+	return "syslog"
+}
+
+func (s *Syslog) Detect(content map[string]interface{}) bool {
+	return field(content, "source") == "syslog" && content["facility"] != nil
+}
+
+func (s *Syslog) Normalize(content map[string]interface{}) m.Normalized {
+	host, app := field(content, "host"), field(content, "app")
+
+	n := m.Normalized{
+		Title:       app,
+		Severity:    syslogSeverities[field(content, "severity")],
+		Resource:    host,
+		Description: field(content, "message"),
+		StartsAt:    parseTime(field(content, "timestamp")),
+		Labels:      labels(object(content, "labels")),
+	}
+
+	if app != "" && host != "" {
+		n.Title = fmt.Sprintf("%s on %s", app, host)
+	} else if n.Title == "" {
+		n.Title = host
+	}
+
+	if n.Labels != nil {
+		n.Labels["facility"] = field(content, "facility")
+	}
+
+	return n
+}
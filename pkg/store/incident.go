@@ -0,0 +1,291 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisIncidentsKey    = "incidents"        // Sorted set of incident IDs by creation time
+	redisActiveKey       = "incidents_active" // Hash of fingerprint -> ID of the firing incidents
+	redisIncidentPrefix  = "incident:"
+	incidentRetention    = 7 * 24 * time.Hour
+	memoryIncidentsLimit = 1000
+)
+
+// IncidentStore records the incidents received, including the suppressed ones
+type IncidentStore struct {
+	mu          sync.RWMutex
+	redisClient *redis.Client
+	incidents   []*m.Incident          // Used without Redis, oldest first
+	active      map[string]*m.Incident // Used without Redis, by fingerprint
+	trackActive bool                   // Whether the firing incidents are indexed, Active() prunes the index
+}
+
+// Global instance for singleton access
+var (
+	incidentStore     *IncidentStore
+	incidentStoreOnce sync.Once
+)
+
+// NewIncidentStore creates a store backed by Redis, or kept in memory when the client is nil.
+// The firing incidents are only indexed with trackActive, for the features that list them with Active,
+// nothing else prunes the index of alerts that never resolve.
+func NewIncidentStore(redisClient *redis.Client, trackActive bool) *IncidentStore {
+	return &IncidentStore{redisClient: redisClient, active: make(map[string]*m.Incident), trackActive: trackActive}
+}
+
+// InitIncidentStore initializes the global singleton instance
+func InitIncidentStore(redisClient *redis.Client, trackActive bool) {
+	incidentStoreOnce.Do(func() {
+		incidentStore = NewIncidentStore(redisClient, trackActive)
+	})
+}
+
+// GetIncidentStore returns the global singleton instance, or nil when it isn't initialized
+func GetIncidentStore() *IncidentStore {
+	return incidentStore
+}
+
+// Save records an incident, incidents are kept for 7 days.
+// A firing incident stays active until the resolved notification with the same fingerprint.
+func (s *IncidentStore) Save(incident *m.Incident) error {
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.trackActive {
+			if incident.Resolved {
+				delete(s.active, incident.Fingerprint)
+			} else {
+				s.active[incident.Fingerprint] = incident
+			}
+		}
+
+		for i, existing := range s.incidents {
+			if existing.ID == incident.ID {
+				s.incidents[i] = incident
+				return nil
+			}
+		}
+
+		s.incidents = append(s.incidents, incident)
+		if len(s.incidents) > memoryIncidentsLimit {
+			s.incidents = s.incidents[len(s.incidents)-memoryIncidentsLimit:]
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return fmt.Errorf("failed to marshal incident: %w", err)
+	}
+
+	ctx := context.Background()
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, redisIncidentPrefix+incident.ID, data, incidentRetention)
+	pipe.ZAdd(ctx, redisIncidentsKey, &redis.Z{Score: float64(incident.CreatedAt.UnixNano()), Member: incident.ID})
+	pipe.ZRemRangeByScore(ctx, redisIncidentsKey, "-inf", fmt.Sprint(time.Now().Add(-incidentRetention).UnixNano()))
+	if s.trackActive {
+		if incident.Resolved {
+			pipe.HDel(ctx, redisActiveKey, incident.Fingerprint)
+		} else {
+			pipe.HSet(ctx, redisActiveKey, incident.Fingerprint, incident.ID)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store incident in Redis: %w", err)
+	}
+
+	return nil
+}
+
+// Removes the active entry of a fingerprint and updates the incident, if the entry is still that incident
+var resolveActiveScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call("HDEL", KEYS[1], ARGV[1])
+redis.call("SET", KEYS[2], ARGV[3], "EX", ARGV[4])
+return 1
+`)
+
+// ResolveActive records a firing incident as resolved, unless a new notification of the same alert
+// replaced it in the meantime. It reports whether the incident was resolved, so that only one
+// instance resolves it when several share the Redis store.
+func (s *IncidentStore) ResolveActive(incident *m.Incident) (bool, error) {
+	resolved := *incident
+	resolved.Resolved = true
+
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if current, ok := s.active[incident.Fingerprint]; !ok || current.ID != incident.ID {
+			return false, nil
+		}
+		delete(s.active, incident.Fingerprint)
+
+		for i, existing := range s.incidents {
+			if existing.ID == incident.ID {
+				s.incidents[i] = &resolved
+			}
+		}
+		return true, nil
+	}
+
+	data, err := json.Marshal(&resolved)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal incident: %w", err)
+	}
+
+	keys := []string{redisActiveKey, redisIncidentPrefix + incident.ID}
+	done, err := resolveActiveScript.Run(context.Background(), s.redisClient, keys,
+		incident.Fingerprint, incident.ID, data, int(incidentRetention.Seconds())).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve incident in Redis: %w", err)
+	}
+
+	return done == 1, nil
+}
+
+// Get returns an incident by ID
+func (s *IncidentStore) Get(id string) (*m.Incident, error) {
+	if s.redisClient == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		for _, incident := range s.incidents {
+			if incident.ID == id {
+				return incident, nil
+			}
+		}
+		return nil, fmt.Errorf("incident '%s' not found", id)
+	}
+
+	data, err := s.redisClient.Get(context.Background(), redisIncidentPrefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("incident '%s' not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident from Redis: %w", err)
+	}
+
+	var incident m.Incident
+	if err := json.Unmarshal(data, &incident); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal incident: %w", err)
+	}
+
+	return &incident, nil
+}
+
+// List returns the most recent incidents first, up to limit
+func (s *IncidentStore) List(limit int) ([]*m.Incident, error) {
+	if s.redisClient == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		var list []*m.Incident
+		for i := len(s.incidents) - 1; i >= 0 && len(list) < limit; i-- {
+			list = append(list, s.incidents[i])
+		}
+		return list, nil
+	}
+
+	ids, err := s.redisClient.ZRevRange(context.Background(), redisIncidentsKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents from Redis: %w", err)
+	}
+
+	return s.getMany(ids)
+}
+
+// Active returns the firing incidents that haven't been resolved
+func (s *IncidentStore) Active() ([]*m.Incident, error) {
+	if s.redisClient == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		list := make([]*m.Incident, 0, len(s.active))
+		for fingerprint, incident := range s.active {
+			// Forget the incidents that expired without being resolved
+			if time.Since(incident.CreatedAt) > incidentRetention {
+				delete(s.active, fingerprint)
+				continue
+			}
+			list = append(list, incident)
+		}
+		return list, nil
+	}
+
+	items, err := s.redisClient.HGetAll(context.Background(), redisActiveKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active incidents from Redis: %w", err)
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, id := range items {
+		ids = append(ids, id)
+	}
+
+	list, err := s.getMany(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forget the incidents that expired without being resolved
+	if len(list) < len(ids) {
+		found := make(map[string]bool, len(list))
+		for _, incident := range list {
+			found[incident.ID] = true
+		}
+		for fingerprint, id := range items {
+			if !found[id] {
+				s.redisClient.HDel(context.Background(), redisActiveKey, fingerprint)
+			}
+		}
+	}
+
+	return list, nil
+}
+
+func (s *IncidentStore) getMany(ids []string) ([]*m.Incident, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisIncidentPrefix + id
+	}
+
+	values, err := s.redisClient.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents from Redis: %w", err)
+	}
+
+	list := make([]*m.Incident, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Expired
+		}
+
+		var incident m.Incident
+		if err := json.Unmarshal([]byte(data), &incident); err != nil {
+			log.Printf("Skipping invalid incident '%s' stored in Redis: %v", ids[i], err)
+			continue
+		}
+		list = append(list, &incident)
+	}
+
+	return list, nil
+}
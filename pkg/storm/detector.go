@@ -0,0 +1,161 @@
+package storm
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+// Item is an incident listed in a digest
+type Item struct {
+	ID       string
+	Title    string
+	Source   string
+	Severity string
+	Status   string
+	Time     string
+}
+
+// Sender delivers a digest to the providers and channels of a route
+type Sender func(digest *m.Incident) error
+
+// Detector counts the incidents of each route and holds them back for digests during a storm
+type Detector struct {
+	mu     sync.Mutex
+	routes map[string]*routeState
+}
+
+type routeState struct {
+	name     string
+	policy   *Policy
+	arrivals []time.Time // Within the last window, oldest first
+	storming bool
+	since    time.Time
+	held     []Item
+	send     Sender
+}
+
+// Global instance for singleton access
+var (
+	detector     *Detector
+	detectorOnce sync.Once
+)
+
+// NewDetector creates a detector without any storm
+func NewDetector() *Detector {
+	return &Detector{routes: make(map[string]*routeState)}
+}
+
+// InitDetector initializes the global singleton instance
+func InitDetector() {
+	detectorOnce.Do(func() {
+		detector = NewDetector()
+	})
+}
+
+// GetDetector returns the global singleton instance, or nil when it isn't initialized
+func GetDetector() *Detector {
+	return detector
+}
+
+// Observe counts an incident of the route and reports whether it is held back for the next digest.
+// A route storms when it receives more than the threshold of incidents within the window, and
+// calms down at the first digest where the rate is back under the threshold.
+func (d *Detector) Observe(route string, policy *Policy, item Item, send Sender) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, ok := d.routes[route]
+	if !ok {
+		s = &routeState{name: route}
+		d.routes[route] = s
+	}
+
+	now := time.Now()
+	s.policy = policy
+	s.send = send
+	s.arrivals = append(prune(s.arrivals, now.Add(-policy.window)), now)
+
+	if !s.storming {
+		if len(s.arrivals) <= policy.Threshold {
+			return false
+		}
+
+		s.storming = true
+		s.since = now
+		log.Printf("Storm on route '%s': %d incidents within %s, sending digests every %s",
+			route, len(s.arrivals), policy.window, policy.interval)
+
+		go d.run(s, policy.interval)
+	}
+
+	s.held = append(s.held, item)
+	return true
+}
+
+// run sends the digests of a storm, the interval is read once since Observe updates the policy
+func (d *Detector) run(s *routeState, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if calm := d.flush(s); calm {
+			return
+		}
+	}
+}
+
+// flush sends the held incidents as a digest and reports whether the storm is over
+func (d *Detector) flush(s *routeState) bool {
+	d.mu.Lock()
+	now := time.Now()
+	s.arrivals = prune(s.arrivals, now.Add(-s.policy.window))
+
+	held := s.held
+	s.held = nil
+
+	calm := len(s.arrivals) <= s.policy.Threshold
+	if calm {
+		s.storming = false
+	}
+
+	digest := newDigest(s, held, now, calm)
+	send := s.send
+	d.mu.Unlock()
+
+	if calm {
+		log.Printf("Storm on route '%s' is over, back to one notification per incident", s.name)
+	}
+
+	if err := send(digest); err != nil {
+		log.Printf("Failed to send the storm digest of route '%s': %v", s.name, err)
+	}
+
+	return calm
+}
+
+func newDigest(s *routeState, held []Item, now time.Time, calm bool) *m.Incident {
+	content := map[string]interface{}{
+		"Storm":     true,
+		"Route":     s.name,
+		"Count":     len(held),
+		"Alerts":    held,
+		"Since":     s.since.Format(time.RFC3339),
+		"Until":     now.Format(time.RFC3339),
+		"Threshold": s.policy.Threshold,
+		"Window":    s.policy.window.String(),
+		"Ended":     calm,
+	}
+
+	return m.NewIncident("", &content, false)
+}
+
+func prune(arrivals []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(arrivals) && arrivals[i].Before(cutoff) {
+		i++
+	}
+	return arrivals[i:]
+}
@@ -0,0 +1,104 @@
+package storm
+
+import (
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	m "github.com/VersusControl/versus-incident/pkg/models"
+)
+
+func TestDetector(t *testing.T) {
+	// A long digest interval keeps the background digests away, the test flushes the route itself
+	policy, err := ParsePolicy(&config.StormConfig{Threshold: 2, Window: "1h", DigestInterval: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var digests []*m.Incident
+	send := func(digest *m.Incident) error {
+		digests = append(digests, digest)
+		return nil
+	}
+
+	d := NewDetector()
+	for i, wantHeld := range []bool{false, false, true, true} {
+		if held := d.Observe("database", policy, Item{ID: string(rune('a' + i))}, send); held != wantHeld {
+			t.Errorf("Observe() of incident %d = %v, want %v", i, held, wantHeld)
+		}
+	}
+	if held := d.Observe("api", policy, Item{ID: "other"}, send); held {
+		t.Error("Observe() held an incident of a route without a storm")
+	}
+
+	s := d.routes["database"]
+
+	tests := []struct {
+		name      string
+		calm      bool // The window elapsed since the last incidents
+		wantCount int
+		wantEnded bool
+	}{
+		{name: "storm goes on", wantCount: 2},
+		{name: "storm is over", calm: true, wantCount: 0, wantEnded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.calm {
+				s.arrivals = nil
+			}
+
+			digests = nil
+			if calm := d.flush(s); calm != tt.wantEnded {
+				t.Errorf("flush() = %v, want %v", calm, tt.wantEnded)
+			}
+			if len(digests) != 1 {
+				t.Fatalf("got %d digests, want 1", len(digests))
+			}
+
+			content := *digests[0].Content
+			if content["Count"] != tt.wantCount || content["Ended"] != tt.wantEnded || content["Route"] != "database" {
+				t.Errorf("digest = %v, want Count %d, Ended %v", content, tt.wantCount, tt.wantEnded)
+			}
+		})
+	}
+
+	if held := d.Observe("database", policy, Item{ID: "e"}, send); held {
+		t.Error("Observe() held an incident after the storm")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *config.StormConfig
+		wantNil      bool
+		wantErr      bool
+		wantTemplate string // Slack digest template
+	}{
+		{name: "no storm config", wantNil: true},
+		{name: "defaults", config: &config.StormConfig{Threshold: 10}, wantTemplate: "config/storm_digest.tmpl"},
+		{name: "custom template", config: &config.StormConfig{Threshold: 10, Templates: map[string]string{"slack": "config/slack_storm.tmpl"}}, wantTemplate: "config/slack_storm.tmpl"},
+		{name: "no threshold", config: &config.StormConfig{}, wantErr: true},
+		{name: "invalid window", config: &config.StormConfig{Threshold: 10, Window: "-1m"}, wantErr: true},
+		{name: "invalid digest interval", config: &config.StormConfig{Threshold: 10, DigestInterval: "often"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (p == nil) != tt.wantNil {
+				t.Fatalf("ParsePolicy() = %v, wantNil %v", p, tt.wantNil)
+			}
+			if p != nil && (p.Templates["slack"] != tt.wantTemplate || p.Templates["email"] != "config/storm_digest_email.tmpl") {
+				t.Errorf("ParsePolicy() templates = %v", p.Templates)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package storm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+// Digest templates used when a storm config doesn't set its own
+var defaultTemplates = map[string]string{
+	"slack":    "config/storm_digest.tmpl",
+	"telegram": "config/storm_digest.tmpl",
+	"viber":    "config/storm_digest.tmpl",
+	"email":    "config/storm_digest_email.tmpl",
+	"msteams":  "config/storm_digest.tmpl",
+	"lark":     "config/storm_digest.tmpl",
+}
+
+// Policy is a parsed storm config
+type Policy struct {
+	config.StormConfig
+
+	window   time.Duration
+	interval time.Duration
+}
+
+// ParsePolicy validates a storm config, it returns nil when there is none
+func ParsePolicy(sc *config.StormConfig) (*Policy, error) {
+	if sc == nil {
+		return nil, nil
+	}
+
+	if sc.Threshold <= 0 {
+		return nil, fmt.Errorf("storm threshold must be greater than 0")
+	}
+
+	p := &Policy{StormConfig: *sc, window: time.Minute, interval: time.Minute}
+
+	if sc.Window != "" {
+		window, err := time.ParseDuration(sc.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid storm window '%s'", sc.Window)
+		}
+		p.window = window
+	}
+
+	if sc.DigestInterval != "" {
+		interval, err := time.ParseDuration(sc.DigestInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid storm digest_interval '%s'", sc.DigestInterval)
+		}
+		p.interval = interval
+	}
+
+	templates := make(map[string]string, len(defaultTemplates))
+	for provider, path := range defaultTemplates {
+		templates[provider] = path
+	}
+	for provider, path := range sc.Templates {
+		templates[provider] = path
+	}
+	p.Templates = templates
+
+	return p, nil
+}
@@ -3,6 +3,7 @@ package utils
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/url"
@@ -236,6 +237,11 @@ func GetTemplateFuncMaps() template.FuncMap {
 			switch v := t.(type) {
 			case time.Time:
 				return v.Format(layout)
+			case *time.Time:
+				if v == nil {
+					return ""
+				}
+				return v.Format(layout)
 			case string:
 				// Try to parse the string as a time
 				parsedTime, err := time.Parse(time.RFC3339, v)
@@ -384,6 +390,15 @@ func GetTemplateFuncMaps() template.FuncMap {
 			match, _ := regexp.MatchString(pattern, s)
 			return match
 		},
+
+		// JSON encoding function, e.g. for building webhook bodies
+		"toJson": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(data), nil
+		},
 	}
 
 	return funcMaps
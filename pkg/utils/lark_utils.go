@@ -29,8 +29,10 @@ type LarkCardTitle struct {
 
 // LarkCardElement represents an element in a Lark card
 type LarkCardElement struct {
-	Tag     string `json:"tag"`
-	Content string `json:"content"`
+	Tag     string         `json:"tag"`
+	Content string         `json:"content,omitempty"`
+	ImgKey  string         `json:"img_key,omitempty"` // Image elements, the key of an uploaded image
+	Alt     *LarkCardTitle `json:"alt,omitempty"`
 }
 
 // CreateLarkMessage creates a Lark message with interactive card format
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseWeekday parses a day of the week by its name or the first three letters of it,
+// case-insensitively, e.g. "Monday", "monday" or "mon"
+func ParseWeekday(value string) (time.Weekday, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if v == name || v == name[:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid day '%s', expected a weekday like monday or mon", value)
+}